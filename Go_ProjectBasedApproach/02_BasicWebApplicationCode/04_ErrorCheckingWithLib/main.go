@@ -3,19 +3,131 @@ package main
 import (
 	"errors"  // Import the errors package for creating error values
 	"fmt"     // Import the fmt package for formatted I/O
+	"math"    // Import math for the math.MaxInt and math.MinInt limits
 	"strconv" // Import strconv for converting strings to integers
 )
 
-// Function to divide two numbers with error handling for division by zero
-func divide(x, y float64) (float64, error) {
+// ErrDivideByZero is returned when the divisor is 0.
+// It is a package level value (a "sentinel"), so callers can check for it with errors.Is(err, ErrDivideByZero)
+var ErrDivideByZero = errors.New("cannot divide by zero")
+
+// Sentinel errors of ParseIntInRange, callers can branch on them with errors.Is
+var (
+	// ErrNotInteger is returned when the string is not a whole number
+	ErrNotInteger = errors.New("not an integer")
+	// ErrOutOfRange is returned when the number is outside the allowed range
+	ErrOutOfRange = errors.New("out of range")
+)
+
+// ErrOverflow is returned when the result doesn't fit in an int. Go doesn't stop on it by itself:
+// math.MaxInt + 1 quietly wraps around to math.MinInt.
+var ErrOverflow = errors.New("integer overflow")
+
+// Integer is every integer type, the ~ also allows types built on them (eg: type Age int)
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is every floating point type
+type Float interface {
+	~float32 | ~float64
+}
+
+// Divide divides two numbers of the same type with error handling for division by zero.
+// It is generic so Divide(10, 3) (integer division, 3) and Divide(10.0, 4.0) (2.5) share one implementation,
+// and dividing integers by zero returns ErrDivideByZero instead of panicking.
+func Divide[T Integer | Float](x, y T) (T, error) {
 	if y == 0 {
-		// Return an error value if division by zero is attempted
-		return 0, errors.New("cannot divide by zero")
+		// Return the sentinel error value if division by zero is attempted
+		return 0, ErrDivideByZero
 	}
 	// If no error, return the division result and nil (no error)
 	return x / y, nil
 }
 
+// Operation is one calculation done by a Calculator, failed ones are recorded too
+type Operation struct {
+	Name     string    // "add", "subtract", "multiply" or "divide"
+	Operands []float64 // The numbers the operation was done with
+	Result   float64   // The result, 0 when the operation failed
+	Err      error     // Why the operation failed, nil when it worked
+}
+
+// Calculator does basic math and remembers every operation it did
+type Calculator struct {
+	history []Operation
+}
+
+// record appends the operation to the history and returns its result and error
+func (c *Calculator) record(name string, x, y, result float64, err error) (float64, error) {
+	c.history = append(c.history, Operation{
+		Name:     name,
+		Operands: []float64{x, y},
+		Result:   result,
+		Err:      err,
+	})
+	return result, err
+}
+
+// Add returns x + y
+func (c *Calculator) Add(x, y float64) float64 {
+	result, _ := c.record("add", x, y, x+y, nil)
+	return result
+}
+
+// Subtract returns x - y
+func (c *Calculator) Subtract(x, y float64) float64 {
+	result, _ := c.record("subtract", x, y, x-y, nil)
+	return result
+}
+
+// Multiply returns x * y
+func (c *Calculator) Multiply(x, y float64) float64 {
+	result, _ := c.record("multiply", x, y, x*y, nil)
+	return result
+}
+
+// Divide returns x / y, or ErrDivideByZero when y is 0 (the failed attempt is still in the history)
+func (c *Calculator) Divide(x, y float64) (float64, error) {
+	result, err := Divide(x, y)
+	return c.record("divide", x, y, result, err)
+}
+
+// History returns a copy of the operations done so far, the oldest first
+func (c *Calculator) History() []Operation {
+	history := make([]Operation, len(c.history))
+	copy(history, c.history)
+	return history
+}
+
+// Reset forgets every operation
+func (c *Calculator) Reset() {
+	c.history = nil
+}
+
+// AddChecked returns a + b, or ErrOverflow when the sum is too big (or too small) for an int
+func AddChecked(a, b int) (int, error) {
+	// adding a positive number can only overflow above MaxInt, adding a negative one only below MinInt
+	if (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b) {
+		return 0, fmt.Errorf("%d + %d: %w", a, b, ErrOverflow)
+	}
+	return a + b, nil
+}
+
+// MultiplyChecked returns a * b, or ErrOverflow when the product is too big (or too small) for an int
+func MultiplyChecked(a, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	// when it wrapped, dividing back doesn't give a again. MinInt * -1 is the one case
+	// where the division itself would overflow, so it is checked on its own.
+	if (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) || product/b != a {
+		return 0, fmt.Errorf("%d * %d: %w", a, b, ErrOverflow)
+	}
+	return product, nil
+}
+
 // Function to convert a string to an integer with error handling
 func stringToInt(s string) (int, error) {
 	// Convert the string to an integer
@@ -28,11 +140,61 @@ func stringToInt(s string) (int, error) {
 	return value, nil
 }
 
+// ParseIntInRange converts the string and checks that the result is between min and max (both included),
+// eg: for a page number from the query string. The returned error wraps ErrNotInteger or ErrOutOfRange.
+func ParseIntInRange(s string, min, max int) (int, error) {
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q: %w", s, ErrNotInteger)
+	}
+	if value < min || value > max {
+		return 0, fmt.Errorf("%d must be between %d and %d: %w", value, min, max, ErrOutOfRange)
+	}
+	return value, nil
+}
+
+// ParseInts converts every string to an integer. It doesn't stop at the first bad value: the errors of every bad
+// index are joined together with errors.Join, and the values that did convert are still returned (in their order)
+// so the caller can decide if a partial result is good enough.
+func ParseInts(inputs []string) ([]int, error) {
+	values := make([]int, 0, len(inputs))
+	var errs []error
+	for i, s := range inputs {
+		value, err := stringToInt(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("index %d: %q: %w", i, s, err))
+			continue
+		}
+		values = append(values, value)
+	}
+	// errors.Join returns nil when there are no errors
+	return values, errors.Join(errs...)
+}
+
+// Function that converts two strings and divides them, the errors from the steps below are wrapped
+// with %w so the caller still can find out what went wrong with errors.Is
+func divideStrings(a, b string) (float64, error) {
+	x, err := stringToInt(a)
+	if err != nil {
+		return 0, fmt.Errorf("divide: %w", err)
+	}
+	y, err := stringToInt(b)
+	if err != nil {
+		return 0, fmt.Errorf("divide: %w", err)
+	}
+	result, err := Divide(float64(x), float64(y))
+	if err != nil {
+		// the message becomes "divide: cannot divide by zero" but the original error is kept inside
+		return 0, fmt.Errorf("divide: %w", err)
+	}
+	return result, nil
+}
+
 func main() {
 	// Example 1: Division with error checking
 	numerator := 10.0
 	denominator := 0.0 // This will trigger a division by zero error
-	result, err := divide(numerator, denominator)
+	result, err := Divide(numerator, denominator)
 	if err != nil {
 		// Handle the division error by printing the error message
 		fmt.Println("Division Error:", err)
@@ -44,7 +206,7 @@ func main() {
 	// Example 2: Valid division
 	numerator = 10.0
 	denominator = 2.0 // Valid input
-	result, err = divide(numerator, denominator)
+	result, err = Divide(numerator, denominator)
 	if err != nil {
 		// Handle the error
 		fmt.Println("Division Error:", err)
@@ -74,4 +236,76 @@ func main() {
 		// Print the valid converted number
 		fmt.Println("Successfully converted number:", validNumber)
 	}
+
+	// Example 5: Checking for a specific error after it was wrapped
+	_, err = divideStrings("10", "0")
+	if errors.Is(err, ErrDivideByZero) {
+		// errors.Is looks through the wrapped errors, so this matches even though the message changed
+		fmt.Println("Wrapped Division Error:", err)
+	} else if err != nil {
+		fmt.Println("Some other error:", err)
+	}
+
+	// Example 6: The same Divide works for integers, the result is an integer too
+	quotient, err := Divide(10, 3)
+	if err != nil {
+		fmt.Println("Division Error:", err)
+	} else {
+		fmt.Println("Result of integer division:", quotient)
+	}
+
+	// dividing an integer by zero would normally panic, here we get the error instead
+	_, err = Divide(int64(10), 0)
+	if errors.Is(err, ErrDivideByZero) {
+		fmt.Println("Integer Division Error:", err)
+	}
+
+	// Example 7: A calculator that remembers what it did, the failed division is in the history too
+	var calc Calculator
+	calc.Add(2, 3)
+	calc.Multiply(4, 5)
+	calc.Divide(1, 0)
+	for _, op := range calc.History() {
+		if op.Err != nil {
+			fmt.Printf("%s %v failed: %v\n", op.Name, op.Operands, op.Err)
+		} else {
+			fmt.Printf("%s %v = %v\n", op.Name, op.Operands, op.Result)
+		}
+	}
+	calc.Reset()
+
+	// Example 8: Converting many strings at once, every bad value is reported and the good ones are kept
+	numbers, err := ParseInts([]string{"1", "2", "123abc", "4", "5", "x"})
+	fmt.Println("Parsed numbers:", numbers)
+	if err != nil {
+		fmt.Println("Parse Errors:")
+		fmt.Println(err)
+	}
+
+	// Example 9: Parsing a number that must be in a range, errors.Is tells the two kinds of failure apart
+	for _, input := range []string{"5", "0", "101", "ten"} {
+		page, err := ParseIntInRange(input, 1, 100)
+		switch {
+		case errors.Is(err, ErrNotInteger):
+			fmt.Println("Not a number:", err)
+		case errors.Is(err, ErrOutOfRange):
+			fmt.Println("Out of range:", err)
+		default:
+			fmt.Println("Page:", page)
+		}
+	}
+
+	// Example 10: Adding and multiplying without silently wrapping around
+	sum, err := AddChecked(math.MaxInt, 1)
+	if errors.Is(err, ErrOverflow) {
+		fmt.Println("Overflow Error:", err)
+	} else {
+		fmt.Println("Sum:", sum)
+	}
+	product, err := MultiplyChecked(math.MaxInt/2, 3)
+	if errors.Is(err, ErrOverflow) {
+		fmt.Println("Overflow Error:", err)
+	} else {
+		fmt.Println("Product:", product)
+	}
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDivideStringsWrapsSentinel(t *testing.T) {
+	_, err := divideStrings("10", "0")
+	if !errors.Is(err, ErrDivideByZero) {
+		t.Fatalf("err = %v, want it to wrap ErrDivideByZero", err)
+	}
+	if err.Error() != "divide: cannot divide by zero" {
+		t.Errorf("err = %q, want the divide: prefix", err)
+	}
+
+	// a failed conversion is wrapped too, but it isn't a division by zero
+	_, err = divideStrings("ten", "2")
+	if err == nil || errors.Is(err, ErrDivideByZero) {
+		t.Errorf("err = %v, want a conversion error", err)
+	}
+
+	result, err := divideStrings("10", "4")
+	if err != nil || result != 2.5 {
+		t.Errorf("got %v %v, want 2.5", result, err)
+	}
+}
+
+// checkDivide divides x by y and by zero, for one numeric type
+func checkDivide[T Integer | Float](t *testing.T, x, y, want T) {
+	t.Helper()
+	got, err := Divide(x, y)
+	if err != nil || got != want {
+		t.Errorf("Divide(%v, %v) = %v, %v, want %v", x, y, got, err, want)
+	}
+	got, err = Divide(x, 0)
+	if !errors.Is(err, ErrDivideByZero) || got != 0 {
+		t.Errorf("Divide(%v, 0) = %v, %v, want ErrDivideByZero", x, got, err)
+	}
+}
+
+func TestDivide(t *testing.T) {
+	t.Run("int", func(t *testing.T) { checkDivide(t, 10, 3, 3) })
+	t.Run("int64", func(t *testing.T) { checkDivide[int64](t, -9, 2, -4) })
+	t.Run("uint8", func(t *testing.T) { checkDivide[uint8](t, 255, 5, 51) })
+	t.Run("float32", func(t *testing.T) { checkDivide[float32](t, 10, 4, 2.5) })
+	t.Run("float64", func(t *testing.T) { checkDivide(t, 1.0, 8.0, 0.125) })
+
+	// types built on a number work too
+	type age int
+	t.Run("named type", func(t *testing.T) { checkDivide[age](t, 30, 2, 15) })
+}
+
+func TestCalculatorHistory(t *testing.T) {
+	var calc Calculator
+	calc.Add(2, 3)
+	calc.Subtract(10, 4)
+	calc.Multiply(4, 5)
+	if _, err := calc.Divide(1, 0); !errors.Is(err, ErrDivideByZero) {
+		t.Errorf("Divide(1, 0) err = %v, want ErrDivideByZero", err)
+	}
+	if got, _ := calc.Divide(9, 3); got != 3 {
+		t.Errorf("Divide(9, 3) = %v, want 3", got)
+	}
+
+	want := []Operation{
+		{Name: "add", Operands: []float64{2, 3}, Result: 5},
+		{Name: "subtract", Operands: []float64{10, 4}, Result: 6},
+		{Name: "multiply", Operands: []float64{4, 5}, Result: 20},
+		{Name: "divide", Operands: []float64{1, 0}, Err: ErrDivideByZero},
+		{Name: "divide", Operands: []float64{9, 3}, Result: 3},
+	}
+	history := calc.History()
+	if !reflect.DeepEqual(history, want) {
+		t.Fatalf("History() = %+v, want %+v", history, want)
+	}
+
+	// the history handed out is a copy
+	history[0].Name = "changed"
+	if calc.History()[0].Name != "add" {
+		t.Error("changing the returned history changed the calculator")
+	}
+
+	calc.Reset()
+	if len(calc.History()) != 0 {
+		t.Errorf("History() after Reset = %v, want it empty", calc.History())
+	}
+}
+
+func TestParseInts(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []string
+		values []int
+		errors []string
+	}{
+		{"all valid", []string{"1", "-2", "30"}, []int{1, -2, 30}, nil},
+		{"all invalid", []string{"a", "", "1.5"}, []int{}, []string{`index 0: "a"`, `index 1: ""`, `index 2: "1.5"`}},
+		{"mixed", []string{"1", "2", "123abc", "4", "5", "x"}, []int{1, 2, 4, 5}, []string{`index 2: "123abc"`, `index 5: "x"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ParseInts(tt.inputs)
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values = %v, want %v", values, tt.values)
+			}
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			// one line per bad value, in the order of the inputs
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.errors) {
+				t.Fatalf("err = %q, want %d lines", err, len(tt.errors))
+			}
+			for i, want := range tt.errors {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("line %d = %q, want it to start with %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIntInRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+		err   error
+	}{
+		{"inside", "50", 50, nil},
+		{"min", "1", 1, nil},
+		{"max", "100", 100, nil},
+		{"below", "0", 0, ErrOutOfRange},
+		{"above", "101", 0, ErrOutOfRange},
+		{"negative", "-5", 0, ErrOutOfRange},
+		{"not a number", "ten", 0, ErrNotInteger},
+		{"empty", "", 0, ErrNotInteger},
+		{"decimal", "1.5", 0, ErrNotInteger},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIntInRange(tt.input, 1, 100)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIntInRange(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddChecked(t *testing.T) {
+	tests := []struct {
+		a, b     int
+		want     int
+		overflow bool
+	}{
+		{2, 3, 5, false},
+		{math.MaxInt, 0, math.MaxInt, false},
+		{math.MaxInt - 1, 1, math.MaxInt, false},
+		{math.MaxInt, 1, 0, true},
+		{math.MinInt, 0, math.MinInt, false},
+		{math.MinInt + 1, -1, math.MinInt, false},
+		{math.MinInt, -1, 0, true},
+		{math.MaxInt, math.MinInt, -1, false},
+	}
+
+	for _, tt := range tests {
+		got, err := AddChecked(tt.a, tt.b)
+		if errors.Is(err, ErrOverflow) != tt.overflow || (!tt.overflow && err != nil) {
+			t.Errorf("AddChecked(%d, %d): err = %v, overflow want %v", tt.a, tt.b, err, tt.overflow)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("AddChecked(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMultiplyChecked(t *testing.T) {
+	tests := []struct {
+		a, b     int
+		want     int
+		overflow bool
+	}{
+		{6, 7, 42, false},
+		{math.MaxInt, 0, 0, false},
+		{math.MaxInt, 1, math.MaxInt, false},
+		{math.MaxInt, -1, -math.MaxInt, false},
+		{math.MaxInt, 2, 0, true},
+		{math.MinInt, 1, math.MinInt, false},
+		{math.MinInt, -1, 0, true},
+		{-1, math.MinInt, 0, true},
+		{math.MinInt / 2, 2, math.MinInt, false},
+		{math.MinInt / 2, -2, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := MultiplyChecked(tt.a, tt.b)
+		if errors.Is(err, ErrOverflow) != tt.overflow || (!tt.overflow && err != nil) {
+			t.Errorf("MultiplyChecked(%d, %d): err = %v, overflow want %v", tt.a, tt.b, err, tt.overflow)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MultiplyChecked(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"02_TemplatesApp/pkg/handlers"
+	"02_TemplatesApp/pkg/router"
 	"fmt"
 	"net/http"
 )
@@ -9,9 +10,12 @@ import (
 const portNumber = ":8080" 
 
 func main() {
-	http.HandleFunc("/home", handlers.Home)
-	http.HandleFunc("/about", handlers.About)
+	// router gives us method aware routing (eg: only GET) using the standard library ServeMux
+	r := router.New()
+
+	r.Handle(http.MethodGet, "/home", handlers.Home)
+	r.Handle(http.MethodGet, "/about", handlers.About)
 
 	fmt.Printf("Starting application on port %s", portNumber)
-	http.ListenAndServe(portNumber, nil)
+	http.ListenAndServe(portNumber, r)
 }
\ No newline at end of file
@@ -0,0 +1,32 @@
+package router
+
+import "net/http"
+
+// Router is a small method aware router built on top of http.ServeMux.
+// Since Go 1.22 the ServeMux understands patterns like "GET /page/{id}", so we get method matching and path params without chi.
+type Router struct {
+	mux *http.ServeMux
+}
+
+// New creates an empty router
+func New() *Router {
+	return &Router{
+		mux: http.NewServeMux(),
+	}
+}
+
+// Handle registers the handler for the given method and pattern, eg: r.Handle(http.MethodGet, "/page/{id}", handler).
+// Requests with a different method get a 405 Method Not Allowed from the ServeMux.
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc) {
+	rt.mux.HandleFunc(method+" "+pattern, handler)
+}
+
+// Param returns the value of a named path param, eg: Param(r, "id") for the pattern "/page/{id}"
+func Param(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+
+// ServeHTTP makes Router a http.Handler so it can be passed to http.ListenAndServe
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
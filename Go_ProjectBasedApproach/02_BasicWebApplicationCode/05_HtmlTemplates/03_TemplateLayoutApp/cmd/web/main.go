@@ -2,6 +2,7 @@ package main
 
 import (
 	"03_TemplateLayoutApp/pkg/handlers"
+	"03_TemplateLayoutApp/pkg/router"
 	"fmt"
 	"net/http"
 )
@@ -9,11 +10,14 @@ import (
 const portNumber = ":8080" 
 
 func main() {
-	http.HandleFunc("/home", handlers.Home)
-	http.HandleFunc("/about", handlers.About)
-	http.HandleFunc("/hometwo", handlers.HomeTwo)
-	http.HandleFunc("/homethree", handlers.HomeThree)
+	// router gives us method aware routing (eg: only GET) using the standard library ServeMux
+	r := router.New()
+
+	r.Handle(http.MethodGet, "/home", handlers.Home)
+	r.Handle(http.MethodGet, "/about", handlers.About)
+	r.Handle(http.MethodGet, "/hometwo", handlers.HomeTwo)
+	r.Handle(http.MethodGet, "/homethree", handlers.HomeThree)
 
 	fmt.Printf("Starting application on port %s", portNumber)
-	http.ListenAndServe(portNumber, nil)
+	http.ListenAndServe(portNumber, r)
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/page/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "page "+Param(r, "id"))
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"param", http.MethodGet, "/page/42", http.StatusOK, "page 42"},
+		{"other param", http.MethodGet, "/page/about-us", http.StatusOK, "page about-us"},
+		{"head is a get", http.MethodHead, "/page/42", http.StatusOK, ""},
+		{"wrong method", http.MethodPost, "/page/42", http.StatusMethodNotAllowed, ""},
+		{"no param", http.MethodGet, "/page/", http.StatusNotFound, ""},
+		{"unknown path", http.MethodGet, "/nope", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			rt.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rr.Code, tt.status)
+			}
+			if tt.body != "" && rr.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rr.Body, tt.body)
+			}
+		})
+	}
+}
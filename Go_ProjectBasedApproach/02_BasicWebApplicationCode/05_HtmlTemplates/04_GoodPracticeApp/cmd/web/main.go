@@ -16,7 +16,7 @@ func main() {
 	var app config.AppConfig
 
 	//This will help in creation of template in the starting of the application and store it in the tc variable 
-	tc, err := render.CreateTemplateCache()
+	tc, err := render.CreateTemplateCache(render.PathToTemplates)
 	if err != nil {
 		log.Fatal("Cannot create template cache")
 	}
@@ -24,6 +24,8 @@ func main() {
 	// will will store the value in the TemplateCache variable  
 	app.TemplateCache = tc
 	app.UseCache = true
+	// where the css/js/images are, relative to cmd/web like the templates
+	app.StaticDir = "../../static"
 
 	repo := handlers.NewRepo(&app)
 	handlers.NewHandlers(repo)
@@ -35,6 +37,10 @@ func main() {
 
 	http.HandleFunc("/home", handlers.Repo.Home)
 	http.HandleFunc("/about", handlers.Repo.About)
+	http.HandleFunc("/about.json", handlers.Repo.AboutJSON)
+
+	// the layout loads /static/css/style.css, StripPrefix removes "/static/" so the file server looks up css/style.css
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(app.StaticDir))))
 
 
 	fmt.Printf("Starting application on port %s", portNumber)
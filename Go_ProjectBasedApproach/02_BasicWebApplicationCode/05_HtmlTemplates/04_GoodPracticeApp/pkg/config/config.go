@@ -12,4 +12,5 @@ import "html/template"
 type AppConfig struct{
   UseCache bool
   TemplateCache map[string]*template.Template
+  StaticDir string // The folder the css/js/images are served from under /static/.
 }
\ No newline at end of file
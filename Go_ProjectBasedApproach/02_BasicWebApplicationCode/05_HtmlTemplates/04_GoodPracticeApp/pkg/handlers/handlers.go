@@ -6,7 +6,10 @@ import (
 	"04_GoodPracticeApp/pkg/config"
 	"04_GoodPracticeApp/pkg/models"
 	"04_GoodPracticeApp/pkg/render"
+	"encoding/json"
+	"log"
 	"net/http"
+	"strings"
 )
 
 // Repo the repository used by the handlers
@@ -31,19 +34,65 @@ func NewHandlers(r *Repository) {
 
 // "H" in home is capital so that it can be accessed from other packages also
 func (m *Repository) Home(w http.ResponseWriter, r *http.Request){
-	render.RenderTemplate(w, "home.page.tmpl", &models.TemplateData{})
+	err := render.RenderTemplate(w, "home.page.tmpl", &models.TemplateData{})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
 }
 
 // "w" send replies to the user of webpage , "r" keeps the request values from the user.
 func (m *Repository) About(w http.ResponseWriter, r *http.Request){
+	// API clients asking for JSON get the same about page as JSON, Vary tells caches the answer depends on Accept
+	w.Header().Add("Vary", "Accept")
+	if negotiate(r) == "json" {
+		m.AboutJSON(w, r)
+		return
+	}
+
 	// creating a map with data
 	stringMap := make(map[string]string)
 	stringMap["test"] = "Hello, again"
 
 	// passing the map with data by matching the fields
-	render.RenderTemplate(w, "about.page.tmpl", &models.TemplateData{
+	err := render.RenderTemplate(w, "about.page.tmpl", &models.TemplateData{
 		StringMap: stringMap, 
 	})
+	if err != nil {
+		log.Println(err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// aboutResponse is the body sent by AboutJSON
+type aboutResponse struct {
+	Message string `json:"message"`
+	Sum     int    `json:"sum"`
+}
+
+// AboutJSON is the about page for API clients, same repository but the answer is JSON instead of HTML
+func (m *Repository) AboutJSON(w http.ResponseWriter, r *http.Request){
+	w.Header().Set("Content-Type", "application/json")
+
+	// json.NewEncoder writes the struct straight to the response
+	err := json.NewEncoder(w).Encode(aboutResponse{
+		Message: "This is the about page",
+		Sum:     addValues(2, 2),
+	})
+	if err != nil {
+		log.Println(err)
+	}
 }
 
+// addValues is a helper function that adds two integers
+func addValues(x, y int) int {
+	return x + y
+}
 
+// negotiate returns "json" when the Accept header asks for JSON and "html" for everything else (browsers, */*)
+func negotiate(r *http.Request) string {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return "json"
+	}
+	return "html"
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"04_GoodPracticeApp/pkg/config"
+	"04_GoodPracticeApp/pkg/render"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testApp is the config the handlers run with in the tests, the template cache is built from the real templates
+var testApp config.AppConfig
+
+func TestMain(m *testing.M) {
+	// the tests run inside pkg/handlers, from there the templates are two folders up like from cmd/web
+	tc, err := render.CreateTemplateCache("../../templates")
+	if err != nil {
+		fmt.Println("cannot create template cache:", err)
+		os.Exit(1)
+	}
+	testApp.TemplateCache = tc
+	testApp.UseCache = true
+
+	NewHandlers(NewRepo(&testApp))
+	render.NewTemplates(&testApp)
+
+	os.Exit(m.Run())
+}
+
+// getRoutes returns the same routes main registers, on their own mux so the tests don't touch http.DefaultServeMux
+func getRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/home", Repo.Home)
+	mux.HandleFunc("/about", Repo.About)
+	mux.HandleFunc("/about.json", Repo.AboutJSON)
+	return mux
+}
+
+func TestHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		method         string
+		expectedStatus int
+	}{
+		{"home", "/home", http.MethodGet, http.StatusOK},
+		{"about", "/about", http.MethodGet, http.StatusOK},
+		{"about json", "/about.json", http.MethodGet, http.StatusOK},
+		{"unknown page", "/nope", http.MethodGet, http.StatusNotFound},
+	}
+
+	ts := httptest.NewServer(getRoutes())
+	defer ts.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.url, resp.StatusCode, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestHandlersMissingTemplate(t *testing.T) {
+	// without the templates the pages answer with a 500 instead of stopping the server
+	old := testApp.TemplateCache
+	testApp.TemplateCache = map[string]*template.Template{}
+	t.Cleanup(func() { testApp.TemplateCache = old })
+
+	for _, path := range []string{"/home", "/about"} {
+		rr := httptest.NewRecorder()
+		getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status = %d, want 500", path, rr.Code)
+		}
+	}
+}
+
+func TestAboutJSON(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Repo.AboutJSON(rr, httptest.NewRequest(http.MethodGet, "/about.json", nil))
+
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body aboutResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Message != "This is the about page" || body.Sum != addValues(2, 2) {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "html"},
+		{"*/*", "html"},
+		{"text/html,application/xhtml+xml", "html"},
+		{"application/json", "json"},
+		{"text/html;q=0.9, application/json", "json"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/about", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := negotiate(req); got != tt.want {
+			t.Errorf("negotiate(Accept: %q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestAboutNegotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		json        bool
+	}{
+		{"browser", "text/html", "text/html; charset=utf-8", false},
+		{"api client", "application/json", "application/json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/about", nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			getRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+
+			var body aboutResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &body)
+			if tt.json && (err != nil || body.Message == "") {
+				t.Errorf("want the JSON body, got %q (%v)", rr.Body, err)
+			}
+			if !tt.json && (err == nil || !strings.Contains(rr.Body.String(), "<html")) {
+				t.Errorf("want the HTML page, got %q", rr.Body)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"04_GoodPracticeApp/pkg/config"
 	"04_GoodPracticeApp/pkg/models"
 	"bytes"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
@@ -13,12 +14,18 @@ import (
 // app is a global variable which is of type *config.AppConfig (pointer since we need to use the value)
 var app *config.AppConfig
 
+// PathToTemplates is the folder the templates are read from when nothing else is given, it is relative to the
+// working directory so it only works when the app is started from cmd/web
+var PathToTemplates = "../../templates"
+
 // NewTemplates sets the config for the template package
 func NewTemplates(a *config.AppConfig){
 	app = a
 }
 
-func RenderTemplate(w http.ResponseWriter, tmpl string, td *models.TemplateData){
+// RenderTemplate renders the template from the cache, it returns an error (instead of stopping the server)
+// when the template doesn't exist or can't be executed so the handler can send a 500
+func RenderTemplate(w http.ResponseWriter, tmpl string, td *models.TemplateData) error {
 	var tc map[string]*template.Template
 
 	if app.UseCache{
@@ -27,22 +34,27 @@ func RenderTemplate(w http.ResponseWriter, tmpl string, td *models.TemplateData)
 		tc = app.TemplateCache
 	}else {
 		println("Creating New Template everytime")
-		tc, _ = CreateTemplateCache()
+		var err error
+		tc, err = CreateTemplateCache(PathToTemplates)
+		if err != nil {
+			return err
+		}
 	}	
 
 	// get requested template from the cache
 	t, ok := tc[tmpl]
 	if !ok{
-		log.Fatal("Could not get template from template cache")
+		return fmt.Errorf("could not get template %q from template cache", tmpl)
 	}
 
 	// bytes.Buffer: A buffer to hold data temporarily in memory before writing it out.
 	buf := new(bytes.Buffer)
 
 	// t.Execute: Executes a template, injecting optional data.
+	// Nothing is sent yet when it fails, so the handler can still answer with an error page.
 	err := t.Execute(buf, td)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
 
 	// render template
@@ -50,17 +62,19 @@ func RenderTemplate(w http.ResponseWriter, tmpl string, td *models.TemplateData)
 	if err != nil {
 		log.Println(err)
 	}
+	return nil
 }
 
 // This is a function to Create Template cache that returns a value that is map which has key : template_name and value : rendered template and a error
-func CreateTemplateCache() (map[string]*template.Template, error){
+// dir is the folder holding the templates, pass PathToTemplates for the default one
+func CreateTemplateCache(dir string) (map[string]*template.Template, error){
 
 	// myCache := make(map[string]*template.Template) //creating map using make keyword
 	myCache := map[string]*template.Template{} //this is creating and empty map without make, keyword both are same
 
-	// get all of the files name *.page.tmpl from ./templates. 
+	// get all of the files name *.page.tmpl from the templates folder. 
 	// filepath.Glob: Returns a list of files matching a glob pattern.
-	pages, err := filepath.Glob("../../templates/*.page.tmpl")
+	pages, err := filepath.Glob(filepath.Join(dir, "*.page.tmpl"))
 	if err != nil{
 		return myCache, err
 	}
@@ -78,14 +92,14 @@ func CreateTemplateCache() (map[string]*template.Template, error){
 			return myCache, err
 		}
 
-		matches, err := filepath.Glob("../../templates/*.layout.tmpl")
+		matches, err := filepath.Glob(filepath.Join(dir, "*.layout.tmpl"))
 		if err != nil{
 			return myCache, err
 		}
 
 		if len(matches) > 0 {
 			//ParseGlob: Parses all template files matching a glob pattern into the template instance.
-			ts, err = ts.ParseGlob("../../templates/*.layout.tmpl")
+			ts, err = ts.ParseGlob(filepath.Join(dir, "*.layout.tmpl"))
 			if err != nil{
 				return myCache, err
 			}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"04_GoodPracticeApp/pkg/config"
+	"04_GoodPracticeApp/pkg/models"
+	"bytes"
+	"html/template"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplates writes the files (name => content) into a new temp dir and returns the dir
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCreateTemplateCache(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.layout.tmpl": `{{define "base"}}<main>{{block "content" .}}{{end}}</main>{{end}}`,
+		"fake.page.tmpl":   `{{template "base" .}}{{define "content"}}fake page{{end}}`,
+		"notes.txt":        `not a template`,
+	})
+
+	cache, err := CreateTemplateCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache) != 1 {
+		t.Fatalf("cached %d templates, want only fake.page.tmpl", len(cache))
+	}
+	ts, ok := cache["fake.page.tmpl"]
+	if !ok {
+		t.Fatal("fake.page.tmpl is not in the cache")
+	}
+
+	// the page was parsed together with the layout
+	var buf bytes.Buffer
+	if err := ts.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<main>fake page</main>" {
+		t.Errorf("fake.page.tmpl = %q", buf.String())
+	}
+}
+
+func TestCreateTemplateCacheEmptyDir(t *testing.T) {
+	cache, err := CreateTemplateCache(t.TempDir())
+	if err != nil || len(cache) != 0 {
+		t.Errorf("got %d templates and %v, want an empty cache", len(cache), err)
+	}
+}
+
+func TestRenderTemplateMissing(t *testing.T) {
+	old := app
+	t.Cleanup(func() { app = old })
+	NewTemplates(&config.AppConfig{UseCache: true, TemplateCache: map[string]*template.Template{}})
+
+	// a missing template is an error for the handler, not the end of the process
+	rr := httptest.NewRecorder()
+	err := RenderTemplate(rr, "nothere.page.tmpl", &models.TemplateData{})
+	if err == nil || !strings.Contains(err.Error(), "nothere.page.tmpl") {
+		t.Errorf("err = %v, want the missing template named", err)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("wrote %q, want nothing so the handler can send the error", rr.Body)
+	}
+}
+
+func TestRenderTemplateExecuteError(t *testing.T) {
+	old := app
+	t.Cleanup(func() { app = old })
+	cache, err := CreateTemplateCache(writeTemplates(t, map[string]string{
+		"bad.page.tmpl": `before {{.NotAField}} after`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewTemplates(&config.AppConfig{UseCache: true, TemplateCache: cache})
+
+	rr := httptest.NewRecorder()
+	if err := RenderTemplate(rr, "bad.page.tmpl", &models.TemplateData{}); err == nil {
+		t.Error("want the execute error")
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("half the page was sent: %q", rr.Body)
+	}
+}
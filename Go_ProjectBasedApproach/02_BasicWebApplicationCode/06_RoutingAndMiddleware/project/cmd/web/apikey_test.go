@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+)
+
+// hashKey is how the keys are stored in the config: the hex sha256 of the key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	validator := apiKeyValidator(map[string]string{
+		"mobile":  hashKey("mobile-key"),
+		"partner": strings.ToUpper(hashKey("partner-key")),
+	})
+	whoami := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := APIClientFromContext(r.Context())
+		io.WriteString(w, id)
+	})
+	handler := APIKeyAuth(validator)(whoami)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		client  string
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer mobile-key"}, http.StatusOK, "mobile"},
+		{"x-api-key", map[string]string{"X-API-Key": "partner-key"}, http.StatusOK, "partner"},
+		{"invalid", map[string]string{"X-API-Key": "guessed"}, http.StatusUnauthorized, ""},
+		{"hash as key", map[string]string{"X-API-Key": hashKey("mobile-key")}, http.StatusUnauthorized, ""},
+		{"basic auth", map[string]string{"Authorization": "Basic bW9iaWxlLWtleQ=="}, http.StatusUnauthorized, ""},
+		{"missing", nil, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/reservations", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != tt.client {
+				t.Errorf("the handler saw client %q, want %q", rr.Body, tt.client)
+			}
+			if tt.status == http.StatusUnauthorized {
+				if rr.Header().Get("WWW-Authenticate") == "" || !strings.Contains(rr.Body.String(), `"status":401`) {
+					t.Errorf("got %v %q, want the JSON 401 with a challenge", rr.Header(), rr.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIRoutesNeedKey(t *testing.T) {
+	withSetting(t, &app.APIKeys, map[string]string{"mobile": hashKey("mobile-key")})
+	mux := routes(audit.NewMemorySink(), http.NotFoundHandler(), nil, auth.NewMemoryUsers())
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reservations", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("/api/reservations without a key = %d, want 401", rr.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestAuditRequests(t *testing.T) {
+	sink := audit.NewMemorySink()
+	handler := auditRequests(sink)(status(http.StatusSeeOther))
+
+	before := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader("name=secret+body"))
+	req = req.WithContext(auth.NewContext(req.Context(), &models.User{ID: 7, Name: "Rahul"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := sink.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.UserID != "7" || e.Method != http.MethodPost || e.Path != "/reservations" || e.Status != http.StatusSeeOther {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Time.Before(before) {
+		t.Errorf("entry time %v is before the request", e.Time)
+	}
+}
+
+func TestAuditRequestsSkipsReads(t *testing.T) {
+	sink := audit.NewMemorySink()
+	handler := auditRequests(sink)(status(http.StatusOK))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/reservations", nil))
+	}
+	if entries := sink.Entries(); len(entries) != 0 {
+		t.Errorf("read requests were audited: %+v", entries)
+	}
+
+	// anonymous writes are audited too, without a user id
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/reservations/1", nil))
+	entries := sink.Entries()
+	if len(entries) != 1 || entries[0].UserID != "" || entries[0].Method != http.MethodDelete {
+		t.Errorf("entries = %+v, want one anonymous DELETE", entries)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/pkg/render"
+)
+
+// authServer runs the session and auth middleware in front of a few test routes:
+// /login?id=7 logs the visitor in, /whoami answers with the user the handler sees
+// and /page renders a page using the currentUser template func
+func authServer(t *testing.T, users auth.Users) (*httptest.Server, *http.Client) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+		if err := auth.Login(r.Context(), app.Session, id); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			io.WriteString(w, "anonymous")
+			return
+		}
+		io.WriteString(w, user.Name)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		render.RenderTemplate(w, r, "reservations.page.tmpl", &models.TemplateData{})
+	})
+
+	ts := httptest.NewServer(SessionLoad(authenticate(users)(mux)))
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ts.Client()
+	client.Jar = jar
+	return ts, client
+}
+
+// get returns the body of the page
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestAuthenticate(t *testing.T) {
+	users := auth.NewMemoryUsers()
+	users.Add(&models.User{ID: 7, Name: "Rahul"})
+	ts, client := authServer(t, users)
+
+	if got := get(t, client, ts.URL+"/whoami"); got != "anonymous" {
+		t.Errorf("before the login the handler sees %q", got)
+	}
+	if page := get(t, client, ts.URL+"/page"); strings.Contains(page, "Logged in as") {
+		t.Error("the page shows a user before the login")
+	}
+
+	get(t, client, ts.URL+"/login?id=7")
+
+	if got := get(t, client, ts.URL+"/whoami"); got != "Rahul" {
+		t.Errorf("after the login the handler sees %q", got)
+	}
+	if page := get(t, client, ts.URL+"/page"); !strings.Contains(page, "Logged in as Rahul") {
+		t.Errorf("the page doesn't show the user: %s", page)
+	}
+}
+
+func TestAuthenticateRemovedUser(t *testing.T) {
+	users := auth.NewMemoryUsers()
+	users.Add(&models.User{ID: 7, Name: "Rahul"})
+	ts, client := authServer(t, users)
+
+	get(t, client, ts.URL+"/login?id=7")
+	users.Remove(7)
+
+	if got := get(t, client, ts.URL+"/whoami"); got != "anonymous" {
+		t.Errorf("a removed user is still logged in as %q", got)
+	}
+	// the id was dropped from the session, adding the user back doesn't log the old session in again
+	users.Add(&models.User{ID: 7, Name: "Rahul"})
+	if got := get(t, client, ts.URL+"/whoami"); got != "anonymous" {
+		t.Errorf("the session still holds the removed user, got %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// setsCookie sets a cookie without any flags and finishes the response with finish
+func setsCookie(finish func(w http.ResponseWriter)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark", Path: "/"})
+		finish(w)
+	})
+}
+
+func TestSecureCookies(t *testing.T) {
+	finishes := map[string]func(w http.ResponseWriter){
+		"write header": func(w http.ResponseWriter) { w.WriteHeader(http.StatusSeeOther) },
+		"write":        func(w http.ResponseWriter) { io.WriteString(w, "hello") },
+		"nothing":      func(w http.ResponseWriter) {},
+	}
+
+	for name, finish := range finishes {
+		t.Run(name, func(t *testing.T) {
+			withSetting(t, &app.InProduction, true)
+			rr := httptest.NewRecorder()
+			secureCookies(setsCookie(finish)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			cookie := rr.Header().Get("Set-Cookie")
+			if !strings.Contains(cookie, "; Secure") || !strings.Contains(cookie, "; HttpOnly") {
+				t.Errorf("production cookie %q is missing Secure or HttpOnly", cookie)
+			}
+		})
+	}
+}
+
+func TestSecureCookiesDevelopment(t *testing.T) {
+	withSetting(t, &app.InProduction, false)
+	rr := httptest.NewRecorder()
+	secureCookies(setsCookie(func(w http.ResponseWriter) {})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rr.Header().Get("Set-Cookie"); got != "theme=dark; Path=/" {
+		t.Errorf("development cookie = %q, want it untouched", got)
+	}
+}
+
+func TestHardenCookie(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"a=1", "a=1; Secure; HttpOnly"},
+		{"a=1; Secure", "a=1; Secure; HttpOnly"},
+		{"a=1; httponly", "a=1; httponly; Secure"},
+		{"a=1; Path=/; SECURE; HttpOnly", "a=1; Path=/; SECURE; HttpOnly"},
+	}
+	for _, tt := range tests {
+		if got := hardenCookie(tt.in); got != tt.want {
+			t.Errorf("hardenCookie(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// dbWaitTimeout is the most time we spend waiting for the database at startup, no matter how many attempts are left
+const dbWaitTimeout = 30 * time.Second
+
+// waitForDB pings the database until it answers. When the app and the database start together (eg: containers)
+// the database is often not ready yet, so we retry with an exponential backoff: base, 2*base, 4*base...
+func waitForDB(db *sql.DB, attempts int, base time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbWaitTimeout)
+	defer cancel()
+
+	return retryPing(ctx, db.PingContext, attempts, base)
+}
+
+// retryPing calls ping up to attempts times, sleeping between tries and giving up early when ctx is done
+func retryPing(ctx context.Context, ping func(context.Context) error, attempts int, base time.Duration) error {
+	var err error
+	delay := base
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = ping(ctx)
+		if err == nil {
+			log.Printf("Connected to the database (attempt %d/%d)", attempt, attempts)
+			return nil
+		}
+		log.Printf("Database not ready (attempt %d/%d): %v", attempt, attempts, err)
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for database: %w", ctx.Err())
+		}
+	}
+
+	return fmt.Errorf("database not ready after %d attempts: %w", attempts, err)
+}
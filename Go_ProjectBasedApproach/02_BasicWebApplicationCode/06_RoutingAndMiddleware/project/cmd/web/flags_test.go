@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/flags"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	handler := featureFlags(flags.NewStatic(map[string]int{"on": 100, "half": 50}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-On", strconv.FormatBool(flags.Enabled(r.Context(), "on")))
+		w.Header().Set("X-Half", strconv.FormatBool(flags.Enabled(r.Context(), "half")))
+	}))
+
+	// the first visit gets a bucket cookie
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != flagBucketCookie || cookies[0].Value == "" || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %v, want the bucket cookie", cookies)
+	}
+	if rr.Header().Get("X-On") != "true" {
+		t.Error("a 100% flag is off in the handler")
+	}
+	half := rr.Header().Get("X-Half")
+
+	// the next visits bring it back, keep their answer and don't get a new cookie
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Header().Get("X-Half") != half {
+			t.Fatal("the visitor's flag changed between requests")
+		}
+		if len(rr.Result().Cookies()) != 0 {
+			t.Error("a returning visitor got a new bucket cookie")
+		}
+	}
+}
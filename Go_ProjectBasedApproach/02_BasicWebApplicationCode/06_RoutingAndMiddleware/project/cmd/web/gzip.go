@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipWriters are reused between responses, a gzip.Writer allocates a lot when it is created
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipResponse compresses the response when the client accepts gzip. Responses that are already compressed
+// (images, archives, fonts...) or already have a Content-Encoding are sent as they are.
+func gzipResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports if the Accept-Encoding header lists gzip (and doesn't turn it off with q=0)
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// gzipResponseWriter decides on the first WriteHeader/Write if the body gets compressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	// status is the code of a WriteHeader that came before the Content-Type was known, it is sent on the first Write
+	status int
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.wroteHeader || g.status != 0 {
+		return
+	}
+	// without a type we can't tell yet if the body is worth compressing, the first Write sniffs it
+	if g.Header().Get("Content-Type") == "" && status != http.StatusNoContent && status != http.StatusNotModified {
+		g.status = status
+		return
+	}
+	g.writeHeader(status)
+}
+
+// writeHeader decides on the compression and sends the headers
+func (g *gzipResponseWriter) writeHeader(status int) {
+	g.wroteHeader = true
+
+	h := g.Header()
+	if status != http.StatusNoContent && status != http.StatusNotModified && shouldCompress(h) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		// the compressed bytes differ from the original ones, so a strong ETag would be wrong for them
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !g.wroteHeader {
+		// net/http would sniff the type from the compressed bytes, so we do it on the real ones first
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		g.writeHeader(cmp.Or(g.status, http.StatusOK))
+	}
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+// Flush sends what is compressed so far, so streamed responses still arrive in pieces
+func (g *gzipResponseWriter) Flush() {
+	g.sendPendingHeader()
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close writes the end of the gzip stream and gives the writer back to the pool
+func (g *gzipResponseWriter) close() {
+	g.sendPendingHeader()
+	if g.gz == nil {
+		return
+	}
+	g.gz.Close()
+	g.gz.Reset(nil)
+	gzipWriters.Put(g.gz)
+	g.gz = nil
+}
+
+// sendPendingHeader sends the status of a WriteHeader that no Write followed, there is no body to sniff so it isn't compressed
+func (g *gzipResponseWriter) sendPendingHeader() {
+	if !g.wroteHeader && g.status != 0 {
+		g.writeHeader(g.status)
+	}
+}
+
+// shouldCompress reports if the response is worth compressing: it isn't encoded yet and its type isn't compressed already
+func shouldCompress(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	ct := strings.ToLower(h.Get("Content-Type"))
+	switch {
+	case ct == "":
+		// net/http would sniff the type from the compressed bytes and call it application/x-gzip
+		return false
+	case strings.HasPrefix(ct, "image/svg"):
+		return true
+	case strings.HasPrefix(ct, "image/"), strings.HasPrefix(ct, "video/"), strings.HasPrefix(ct, "audio/"),
+		strings.HasPrefix(ct, "font/woff"):
+		return false
+	case strings.Contains(ct, "zip"), strings.Contains(ct, "compressed"), strings.Contains(ct, "application/octet-stream"):
+		return false
+	}
+	return true
+}
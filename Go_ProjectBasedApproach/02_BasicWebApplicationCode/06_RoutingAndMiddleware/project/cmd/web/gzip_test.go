@@ -0,0 +1,114 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testPage = "<!doctype html><html><body><h1>Hello</h1></body></html>"
+
+// page answers with testPage, the content type is left for the writer to sniff like RenderTemplate does
+var page = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, testPage)
+})
+
+func TestGzipResponse(t *testing.T) {
+	png := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, "\x89PNG not really")
+	})
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		acceptEncoding string
+		gzipped        bool
+		body           string
+	}{
+		{"accepts gzip", page, "gzip, deflate", true, testPage},
+		{"no header", page, "", false, testPage},
+		{"gzip turned off", page, "gzip;q=0, deflate", false, testPage},
+		{"already compressed type", png, "gzip", false, "\x89PNG not really"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/home", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			gzipResponse(tt.handler).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			body := rr.Body.String()
+			if tt.gzipped {
+				if rr.Header().Get("Content-Encoding") != "gzip" {
+					t.Fatalf("Content-Encoding = %q, want gzip", rr.Header().Get("Content-Encoding"))
+				}
+				if got := rr.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+					t.Errorf("Content-Type = %q, sniffed from the compressed bytes?", got)
+				}
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatalf("the gzip stream is not complete: %v", err)
+				}
+				body = string(b)
+			} else if got := rr.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestGzipResponseWriteHeaderWithoutType(t *testing.T) {
+	// the handler sends its status before the body and never sets a type
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, testPage)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/reservations", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	gzipResponse(created).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", rr.Code)
+	}
+	// the type is sniffed from the page, not from the gzip bytes
+	if got := rr.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("the page was not compressed")
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != testPage {
+		t.Errorf("body = %q, want the page", b)
+	}
+
+	// a status without a body is still sent, uncompressed
+	empty := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	rr = httptest.NewRecorder()
+	gzipResponse(empty).ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted || rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+		t.Errorf("got %d %v %q, want a plain empty 202", rr.Code, rr.Header(), rr.Body)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/metrics"
+)
+
+func TestWorkerPoolPanics(t *testing.T) {
+	withSetting(t, &appMetrics, metrics.New())
+	var logs bytes.Buffer
+	jobs := newWorkerPool(&config.AppConfig{ErrorLog: slog.New(slog.NewTextHandler(&logs, nil))}, 1, 10)
+
+	var ran atomic.Bool
+	jobs.Submit(func(ctx context.Context) error { panic("template data was nil") })
+	jobs.Submit(func(ctx context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+	if err := jobs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pool survived the panic and ran the next job
+	if !ran.Load() {
+		t.Error("the job after the panicking one did not run")
+	}
+	// the panic went into the structured error log with its stack
+	out := logs.String()
+	if !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "template data was nil") || !strings.Contains(out, "goroutine") {
+		t.Errorf("log = %q, want the panic and its stack as an error", out)
+	}
+	if got := appMetrics.Snapshot().Counters["jobs_panicked"]; got != 1 {
+		t.Errorf("jobs_panicked = %d, want 1", got)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// status answers every request with the status
+func status(code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	})
+}
+
+func TestLogRequestSkipPaths(t *testing.T) {
+	withSetting(t, &app.LogSkipPaths, []string{"/healthz", "/readyz", "/static/"})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		logged bool
+	}{
+		{"health check", "/healthz", http.StatusOK, false},
+		{"failing health check", "/healthz", http.StatusInternalServerError, true},
+		{"static file", "/static/css/site.css", http.StatusOK, false},
+		{"missing static file", "/static/nope.css", http.StatusNotFound, true},
+		{"redirect is not an error", "/readyz", http.StatusFound, false},
+		{"prefix needs the slash", "/healthzz", http.StatusOK, true},
+		{"page", "/home", http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureLog(t)
+			rr := httptest.NewRecorder()
+			LogRequest(status(tt.status)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			logged := strings.Contains(out.String(), "GET "+tt.path+" ")
+			if logged != tt.logged {
+				t.Errorf("logged = %v, want %v: %q", logged, tt.logged, out)
+			}
+		})
+	}
+}
+
+func TestLogRequestStatus(t *testing.T) {
+	mux := chi.NewRouter()
+	mux.Use(LogRequest)
+	mux.Get("/home", func(w http.ResponseWriter, r *http.Request) {
+		// no WriteHeader, the implicit 200 is logged
+		w.Write([]byte("hi"))
+	})
+	mux.Post("/reservations", status(http.StatusSeeOther).ServeHTTP)
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/missing", "GET /missing 404 "},
+		{http.MethodGet, "/home", "GET /home 200 "},
+		{http.MethodPost, "/reservations", "POST /reservations 303 "},
+		{http.MethodDelete, "/home", "DELETE /home 405 "},
+	}
+	for _, tt := range tests {
+		out := captureLog(t)
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+		line := out.String()
+		if !strings.Contains(line, tt.want) {
+			t.Errorf("log = %q, want %q", line, tt.want)
+			continue
+		}
+		// the duration follows the status
+		fields := strings.Fields(line[strings.Index(line, tt.want):])
+		if _, err := time.ParseDuration(fields[3]); err != nil {
+			t.Errorf("no duration after the status in %q", line)
+		}
+	}
+}
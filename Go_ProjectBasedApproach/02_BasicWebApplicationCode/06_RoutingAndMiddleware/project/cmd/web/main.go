@@ -1,46 +1,457 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
 	"github.com/rahulrai17/porject/pkg/config"
 	"github.com/rahulrai17/porject/pkg/handlers"
+	"github.com/rahulrai17/porject/pkg/health"
+	"github.com/rahulrai17/porject/pkg/lifecycle"
+	"github.com/rahulrai17/porject/pkg/logging"
+	"github.com/rahulrai17/porject/pkg/migrations"
+	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/pkg/netlimit"
 	"github.com/rahulrai17/porject/pkg/render"
+	"github.com/rahulrai17/porject/pkg/repository"
+	"github.com/rahulrai17/porject/pkg/repository/sqlstore"
+	"github.com/rahulrai17/porject/pkg/worker"
+	"github.com/rahulrai17/porject/templates"
+
+	// the pgx driver registers itself with database/sql under the name "pgx"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-const portNumber = ":8080"
+// defaultPort is used when neither the -port flag nor the PORT env var is set
+const defaultPort = "8080"
+
+//this will create a variable app of type Appconfig from config.go file, it is package level so the middlewares can read it too
+var app config.AppConfig
+
+// appHealth holds the readiness checks served on /readyz, the parts of the app register theirs when they start
+var appHealth = health.New()
 
 func main() {
-	//this will create a variable app of type Appconfig from config.go file 
-	var app config.AppConfig
 
-	//This will help in creation of template in the starting of the application and store it in the tc variable 
-	tc, err := render.CreateTemplateCache()
+	// the port comes from -port, then from the config (PORT or the config file), eg: "web -port 9090" to run a second instance next to the first
+	portFlag := flag.String("port", "", "the port to listen on (default $PORT, the config file or "+defaultPort+")")
+	configFlag := flag.String("config", "", "the JSON config file (default "+config.DefaultConfigFile+" when it exists)")
+	flag.Parse()
+
+	// the settings that change between machines come from the config file and the env vars, see config.Load.
+	// Load also refuses to start a production server with an insecure or incomplete config.
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app = *cfg
+
+	portNumber, err := resolvePort(*portFlag, app.Port)
 	if err != nil {
-		log.Fatal("Cannot create template cache")
+		log.Fatal(err)
 	}
 
-	// will will store the value in the TemplateCache variable  
-	app.TemplateCache = tc
-	app.UseCache = true
+	// a template name found in two of the TemplateDirs stops the app from starting
+	app.StrictTemplates = true
+	// in development a template asking for data the handler didn't give is an error, so it is noticed right away,
+	// in production the page still renders (with "<no value>") instead of every visitor getting a 500
+	app.StrictMissingKeys = !app.InProduction
 
-	repo := handlers.NewRepo(&app)
-	handlers.NewHandlers(repo)
+	// the file name patterns of the templates, other teams might use eg: *.gohtml
+	app.PageGlob = "*.page.tmpl"
+	app.LayoutGlob = "*.layout.tmpl"
+	app.PartialGlob = "partials/*.partial.tmpl"
+	// the pages that don't use base.layout.tmpl
+	app.PageLayouts = map[string]string{"admin.page.tmpl": "admin.layout.tmpl"}
 
 	// this will pass reference to the AppConfig struct
 	render.NewTemplates(&app)
 
+	// structured logs: text in the terminal during development, JSON in production
+	app.InfoLog = logging.New(os.Stdout, app.InProduction)
+	app.ErrorLog = logging.New(os.Stderr, app.InProduction)
+	// in production the templates come from the binary itself, so only the binary has to be deployed
+	if app.InProduction {
+		app.TemplateFS = templates.FS
+	}
+	if app.BaseURL == "" {
+		app.BaseURL = "http://localhost" + portNumber
+	}
+	app.MinifyHTML = true
+
+	// sessions keep the flash messages (and later the login) between requests
+	app.SessionIdleTimeout = 30 * time.Minute
+	app.SessionLifetime = 24 * time.Hour
+	app.Session = newSessionManager(&app)
+	// no page of this site comes close to this, a bigger one means a template bug
+	app.MaxResponseBytes = 5 << 20
+	// health checks and static files would flood the access log, so they are only logged when they fail
+	app.LogSkipPaths = []string{"/healthz", "/readyz", "/static/"}
+	// these pages never change between requests so browsers can revalidate them with If-Modified-Since,
+	// render still sends the full page when it shows a flash message or the logged in user
+	app.ConditionalPages = []string{"home.page.tmpl", "about.page.tmpl"}
+	// link preview tags for pages that don't set their own
+	app.DefaultOpenGraph = models.OpenGraph{
+		Title:       "Learning Golang",
+		Description: "Learning web development with Go",
+		Image:       app.BaseURL + "/static/images/preview.png",
+	}
+	// values every template can use without the handler setting them
+	app.DefaultTemplateData = &models.TemplateData{
+		StringMap: map[string]string{"site_name": "Learning Golang"},
+		Data:      map[string]interface{}{"base_url": app.BaseURL},
+	}
+
+	// database settings, without a DSN (DATABASE_URL) everything is kept in memory
+	app.DBMaxOpenConns = 10
+	app.DBMaxIdleConns = 5
+	app.DBConnMaxLifetime = 5 * time.Minute
+
+	// the parts of the app are started in this order and stopped in the reverse one
+	lc := lifecycle.New()
+	var (
+		db        *sql.DB
+		store     repository.DataStore
+		jobs      *worker.Pool
+		auditSink audit.Sink
+	)
+
+	//This will help in creation of template in the starting of the application and store it in the TemplateCache variable
+	lc.OnStart(func() error {
+		err := render.ValidateTemplateGlobs()
+		if err != nil {
+			return err
+		}
+		tc, err := render.CreateTemplateCache()
+		if err != nil {
+			return fmt.Errorf("cannot create template cache: %w", err)
+		}
+		app.TemplateCache = tc
+		appHealth.Register(health.CheckerFunc("templates", func(ctx context.Context) error {
+			if len(app.TemplateCache) == 0 {
+				return errors.New("template cache is not loaded")
+			}
+			return nil
+		}))
+		return nil
+	})
+
+	lc.OnStart(func() error {
+		var err error
+		db, err = openDB(&app)
+		if err != nil {
+			return fmt.Errorf("cannot connect to the database: %w", err)
+		}
+		if db == nil {
+			return nil
+		}
+		// close the connection pool when the app stops
+		lc.OnStop(db.Close)
+		appHealth.Register(health.CheckerFunc("database", db.PingContext))
+
+		// the database might still be starting up, so give it a few tries before giving up
+		return waitForDB(db, 5, 500*time.Millisecond)
+	})
+
+	// "web migrate up|down" only connects to the database, runs the migrations and exits
+	if flag.Arg(0) == "migrate" {
+		err = lc.Start()
+		if err != nil {
+			log.Fatal(err)
+		}
+		migrateErr := runMigrateCommand(db, flag.Args()[1:])
+		err = lc.Stop()
+		if err != nil {
+			log.Println("Error during shutdown: ", err)
+		}
+		// exit non-zero only now, log.Fatal would have skipped the stop hooks
+		if migrateErr != nil {
+			log.Fatal(migrateErr)
+		}
+		return
+	}
+
+	// bring the schema up to date before anything uses it, the app refuses to start on a failed migration
+	lc.OnStart(func() error {
+		if db == nil {
+			return nil
+		}
+		err := migrations.Up(db)
+		if err != nil {
+			return fmt.Errorf("cannot migrate the database: %w", err)
+		}
+		return nil
+	})
+
+	lc.OnStart(func() error {
+		var err error
+		store, err = openStore(db)
+		if err != nil {
+			return fmt.Errorf("cannot open the data store: %w", err)
+		}
+		// stores holding resources (eg: prepared statements) are closed on shutdown too
+		if closer, ok := store.(io.Closer); ok {
+			lc.OnStop(closer.Close)
+		}
+		return nil
+	})
+
+	// background jobs (eg: confirmation emails) run on a small pool, the queued ones are finished before the app exits
+	lc.OnStart(func() error {
+		jobs = newWorkerPool(&app, 4, 100)
+		lc.OnStop(func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return jobs.Shutdown(ctx)
+		})
+		return nil
+	})
+
+	lc.OnStart(func() error {
+		var err error
+		auditSink, err = openAuditSink(&app)
+		if err != nil {
+			return fmt.Errorf("cannot open the audit log: %w", err)
+		}
+		if closer, ok := auditSink.(io.Closer); ok {
+			lc.OnStop(closer.Close)
+		}
+		return nil
+	})
+
+	err = lc.Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// outbound HTTP calls made by the handlers must finish within this time
+	app.HTTPClientTimeout = 10 * time.Second
+
+	// the handlers read and write their data through this store
+	repo := handlers.NewRepo(&app, store, jobs)
+	handlers.NewHandlers(repo)
+
+	// features that are shipped dark or only to a part of the visitors
+	app.FeatureFlags = map[string]int{
+		"reservation_count": 100,
+	}
+
+	// api clients come from API_KEYS (see config.Load), without any the api refuses every request
+	if len(app.APIKeys) == 0 {
+		log.Println("No API_KEYS set, the /api/ routes will answer 401")
+	}
+	if app.AdminUser == "" || app.AdminPasswordHash == "" {
+		log.Println("No ADMIN_USER or ADMIN_PASSWORD_HASH set, /admin will answer 401")
+	}
+
+	// connection limits, these protect against a single client opening so many connections that nobody else gets one
+	app.MaxConnsPerIP = 50
+	app.MaxConns = 1000
+	// request limits per client IP: 10 per second on average, with bursts of 30 (a page loading its css/js/images)
+	app.RateLimitRPS = 10
+	app.RateLimitBurst = 30
+	// a handler that takes longer than this is answered with a 503
+	app.RequestTimeout = 30 * time.Second
+
+	// the users a session can be logged in as, there is no login page yet so for now nobody is logged in
+	users := auth.NewMemoryUsers()
+
+	static, err := staticHandler(&app)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(app.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// This is a better way to define start the server.
 	srv := &http.Server{
 		Addr: portNumber,
-		Handler: routes(),
+		Handler: routes(auditSink, static, trustedProxies, users),
 	}
 	
-	fmt.Printf("Starting server at %s\n", portNumber)
-	
-	//http.ListenAndServe(portNumber, routes())
-  err = srv.ListenAndServe();
-	log.Fatal(err)
-}
\ No newline at end of file
+	// ctx is cancelled when we get Ctrl+C (SIGINT) or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// in development a changed template is rebuilt as soon as it is saved, instead of on every request
+	if !app.InProduction {
+		err = render.StartTemplateWatcher(ctx)
+		if err != nil {
+			log.Println("template watcher not started, the templates are rebuilt on every request:", err)
+		}
+	}
+
+	err = runServer(srv, ctx)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// the server is stopped so nothing uses the resources anymore
+	err = lc.Stop()
+	if err != nil {
+		log.Println("Error during shutdown: ", err)
+	}
+}
+
+// newSessionManager creates the session manager with the timeouts of the config: a session expires when it wasn't
+// used for SessionIdleTimeout, or SessionLifetime after it was created, whichever comes first
+func newSessionManager(a *config.AppConfig) *scs.SessionManager {
+	session := scs.New()
+	session.IdleTimeout = a.SessionIdleTimeout
+	session.Lifetime = a.SessionLifetime
+	session.Cookie.Persist = true
+	session.Cookie.SameSite = http.SameSiteLaxMode
+	session.Cookie.Secure = a.InProduction
+	return session
+}
+
+// resolvePort picks the port from the flag, then the config (see config.Load), then defaultPort, and returns it as a listen address (eg: ":8080").
+// Anything that isn't a number from 1 to 65535 is an error, so a typo stops the app instead of listening somewhere unexpected.
+func resolvePort(flagVal string, configured string) (string, error) {
+	port, source := defaultPort, "default"
+	if flagVal != "" {
+		port, source = flagVal, "-port flag"
+	} else if configured != "" {
+		port, source = configured, "config"
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q from the %s: it must be a number from 1 to 65535", port, source)
+	}
+	return ":" + strconv.Itoa(n), nil
+}
+
+// shutdownTimeout is how long the requests still running get to finish once we are asked to stop
+const shutdownTimeout = 10 * time.Second
+
+// runServer serves until ctx is cancelled, then shuts the server down gracefully: new connections are refused and
+// the requests in flight get shutdownTimeout to finish. It returns an error when the server can't start or
+// the shutdown doesn't finish in time.
+func runServer(srv *http.Server, ctx context.Context) error {
+	// we open the listener ourselves so it can be wrapped by the connection limiter
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	ln = netlimit.Listener(ln, app.MaxConnsPerIP, app.MaxConns)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Starting server at %s\n", ln.Addr())
+		//http.ListenAndServe(portNumber, routes())
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// the server stopped by itself, without being asked to
+		return err
+	case <-ctx.Done():
+	}
+	log.Println("Shutting down the server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutting down the server: %w", err)
+	}
+
+	err = <-serveErr
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// openDB opens the database connection pool, it returns nil when no DSN is configured
+func openDB(a *config.AppConfig) (*sql.DB, error) {
+	if a.DSN == "" {
+		return nil, nil
+	}
+
+	db, err := sql.Open("pgx", a.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	// connection pool settings
+	db.SetMaxOpenConns(a.DBMaxOpenConns)
+	db.SetMaxIdleConns(a.DBMaxIdleConns)
+	db.SetConnMaxLifetime(a.DBConnMaxLifetime)
+
+	return db, nil
+}
+
+// newWorkerPool starts the pool for the background jobs. Failed and panicking jobs are logged to the structured error log
+// (the pool logs through a *log.Logger) and the panicking ones show up on /metrics as jobs_panicked.
+func newWorkerPool(a *config.AppConfig, workers, queueSize int) *worker.Pool {
+	jobs := worker.New(workers, queueSize)
+	jobs.ErrorLog = slog.NewLogLogger(a.ErrorLog.Handler(), slog.LevelError)
+	jobs.OnPanic = func() { appMetrics.Inc("jobs_panicked") }
+	return jobs
+}
+
+// openAuditSink returns the file audit sink when a path is configured, otherwise the in-memory one
+func openAuditSink(a *config.AppConfig) (audit.Sink, error) {
+	if a.AuditLogPath == "" {
+		return audit.NewMemorySink(), nil
+	}
+
+	return audit.NewFileSink(a.AuditLogPath)
+}
+
+// openStore returns the SQL store when there is a database, otherwise the in-memory one
+func openStore(db *sql.DB) (repository.DataStore, error) {
+	if db == nil {
+		log.Println("No DATABASE_URL set, using the in-memory store")
+		return repository.NewMemoryStore(), nil
+	}
+	return sqlstore.NewStore(db)
+}
+
+// runMigrateCommand handles "web migrate up" and "web migrate down", it returns the error instead of exiting
+// so main can still stop the lifecycle (and close the database) first
+func runMigrateCommand(db *sql.DB, args []string) error {
+	if db == nil {
+		return errors.New("migrate: DATABASE_URL is not set")
+	}
+	if len(args) != 1 {
+		return errors.New("usage: web migrate up|down")
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrations.Up(db)
+	case "down":
+		err = migrations.Down(db)
+	default:
+		return errors.New("usage: web migrate up|down")
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	log.Printf("migrate %s done", args[0])
+	return nil
+}
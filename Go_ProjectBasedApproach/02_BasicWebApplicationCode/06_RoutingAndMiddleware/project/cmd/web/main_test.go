@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/render"
+)
+
+func TestMain(m *testing.M) {
+	// the middleware write to the standard logger, the tests check responses rather than log lines
+	log.SetOutput(io.Discard)
+
+	// the real templates, the tests run in cmd/web just like the app does
+	app.TemplateDirs = []string{"../../templates"}
+	app.InfoLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+	app.ErrorLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+	app.Session = scs.New()
+	// the limits main sets, with none a router from routes() would refuse every request
+	app.RateLimitRPS = 10
+	app.RateLimitBurst = 30
+	render.NewTemplates(&app)
+
+	os.Exit(m.Run())
+}
+
+// captureLog sends the standard logger into the returned buffer until the test ends
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(io.Discard) })
+	return &buf
+}
+
+// withSetting changes a field of the app config for one test, eg: withSetting(t, &app.InProduction, true)
+func withSetting[T any](t *testing.T, field *T, value T) {
+	t.Helper()
+	old := *field
+	*field = value
+	t.Cleanup(func() { *field = old })
+}
+
+func TestResolvePort(t *testing.T) {
+	tests := []struct {
+		name       string
+		flagVal    string
+		configured string
+		want       string
+		err        string
+	}{
+		{"flag wins", "9000", "7000", ":9000", ""},
+		{"config when no flag", "", "7000", ":7000", ""},
+		{"default", "", "", ":" + defaultPort, ""},
+		{"not a number", "http", "", "", `invalid port "http" from the -port flag`},
+		{"zero", "", "0", "", `invalid port "0" from the config`},
+		{"too big", "65536", "", "", `invalid port "65536"`},
+		{"bad config with a good flag", "8081", "nope", ":8081", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePort(tt.flagVal, tt.configured)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolvePort(%q, %q) = %q, %v, want %q", tt.flagVal, tt.configured, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/metrics"
+)
+
+func TestRoutePatternLabels(t *testing.T) {
+	withSetting(t, &appMetrics, metrics.New())
+	out := captureLog(t)
+
+	mux := chi.NewRouter()
+	mux.Use(LogRequest, collectMetrics)
+	mux.Get("/page/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/page/123", "/page/456", "/random/path"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	routes := appMetrics.Snapshot().Routes
+	if routes["/page/{id}"] != 2 {
+		t.Errorf("routes = %v, want both pages under /page/{id}", routes)
+	}
+	if routes[unmatchedRoute] != 1 {
+		t.Errorf("routes = %v, want the 404 under %q", routes, unmatchedRoute)
+	}
+	for route := range routes {
+		if strings.Contains(route, "123") || strings.Contains(route, "random") {
+			t.Errorf("the raw path %q became a label", route)
+		}
+	}
+
+	// the access log keeps the raw path but labels it with the pattern too
+	if !strings.Contains(out.String(), "GET /page/123 200") || !strings.Contains(out.String(), "route=/page/{id}") {
+		t.Errorf("log = %q", out)
+	}
+}
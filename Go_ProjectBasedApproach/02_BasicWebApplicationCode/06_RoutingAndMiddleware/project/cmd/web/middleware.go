@@ -1,10 +1,37 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"github.com/rahulrai17/porject/pkg/flags"
+	"github.com/rahulrai17/porject/pkg/metrics"
+	"github.com/rahulrai17/porject/pkg/ratelimit"
+	"github.com/rahulrai17/porject/pkg/render"
+	"github.com/rahulrai17/porject/pkg/requestid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// appMetrics holds the request metrics of the whole application, it is served on /metrics
+var appMetrics = metrics.New()
+
 //this is a custom middle ware function i am creating, also its pretty common to name your param as next for middleware.
 func writeToConsole(next http.Handler) http.Handler {
 	// Return an http.HandlerFunc which is a function that implements the http.Handler interface
@@ -14,4 +41,601 @@ func writeToConsole(next http.Handler) http.Handler {
 			// Call the next handler in the chain, passing along the ResponseWriter and Request
 			next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// collectMetrics times every request and records the latency in the metrics histogram
+func collectMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		appMetrics.Observe(routePattern(r), time.Since(start))
+	})
+}
+
+// unmatchedRoute is the label used for requests that didn't match any route (404s), so random paths don't each get their own label
+const unmatchedRoute = "unmatched"
+
+// routePattern returns the chi route pattern that matched the request (eg: /page/{id} instead of /page/123).
+// chi keeps its route context in the request context and fills it while routing, so this only works once next.ServeHTTP returned.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return unmatchedRoute
+	}
+	return rctx.RoutePattern()
+}
+
+// rateLimit answers 429 Too Many Requests to a client IP going over the limiter's rate, Retry-After says when to come back.
+// It must run after RealIP so clients behind our proxies each get their own bucket.
+func rateLimit(l *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
+
+			ok, retryAfter := l.Allow(ip)
+			if !ok {
+				// Retry-After is in whole seconds, rounded up so the client doesn't come back too early
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxRequestIDLength is the longest incoming X-Request-ID we keep, longer ones are replaced by our own
+const maxRequestIDLength = 128
+
+// requestID gives every request an id, it is put in the request context (for the logs and outbound calls) and in the
+// X-Request-ID response header so a user reporting a problem can tell us which request it was. An id sent by the
+// client or a proxy in front of us is kept as it is, so one id can be followed across services.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !validRequestID(id) {
+			id = newUUID()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// RequestIDFromContext returns the id the requestID middleware gave the request
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	return requestid.FromContext(ctx)
+}
+
+// validRequestID reports if an incoming id is safe to keep: not empty, not too long and only printable ascii,
+// so it can't break our log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random (version 4) UUID, eg: "3b241101-e2bb-4255-8caf-4136c566a962"
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// statusRecorder wraps the http.ResponseWriter so we can find out which status code the handler sent
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader remembers the status before passing it on to the real ResponseWriter
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// LogRequest writes one access log line per request with the method, path, status and how long it took.
+// Paths in app.LogSkipPaths are only logged when the response is an error (4xx/5xx).
+func LogRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		// status is 200 by default since handlers that only call Write never call WriteHeader
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status < 400 && skipLogging(r.URL.Path) {
+			return
+		}
+		id, _ := RequestIDFromContext(r.Context())
+		log.Printf("%s %s %d %s route=%s remote=%s request_id=%s", r.Method, r.URL.Path, rec.status, time.Since(start), routePattern(r), r.RemoteAddr, id)
+	})
+}
+
+// skipLogging reports if the path is in the skip list, either exactly or by a prefix ending in "/"
+func skipLogging(path string) bool {
+	for _, skip := range app.LogSkipPaths {
+		if path == skip || (strings.HasSuffix(skip, "/") && strings.HasPrefix(path, skip)) {
+			return true
+		}
+	}
+	return false
+}
+
+// secureCookies makes sure no cookie leaves the server without the Secure and HttpOnly flags in production,
+// even if a handler forgot to set them. In development cookies are left untouched so they still work over plain http.
+func secureCookies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.InProduction {
+			next.ServeHTTP(w, r)
+			return
+		}
+		hardener := &cookieHardener{ResponseWriter: w}
+		next.ServeHTTP(hardener, r)
+		// a handler that wrote nothing gets its implicit 200 from net/http, which wouldn't go through our WriteHeader
+		if !hardener.wroteHeader {
+			hardener.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+// hstsMaxAge is how long (in seconds) the browser must only use https for our host once it has seen HSTS: one year
+const hstsMaxAge = 365 * 24 * 60 * 60
+
+// secureHeaders sets the security headers on every response and in production sends plain http requests
+// to the https address with a 308 (the browser keeps the method and body, unlike a 301).
+// TLS ends at the proxy in front of us, so X-Forwarded-Proto says if the client used https.
+func secureHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", hstsMaxAge))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", "DENY")
+
+		if app.InProduction && !isHTTPS(r) {
+			http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isHTTPS reports if the client reached us over https, directly or through the proxy
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// cookieHardener rewrites the Set-Cookie headers just before they are sent
+type cookieHardener struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader hardens the cookies, headers can't be changed after this call
+func (c *cookieHardener) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		cookies := c.Header().Values("Set-Cookie")
+		for i, cookie := range cookies {
+			cookies[i] = hardenCookie(cookie)
+		}
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends an implicit 200 through our WriteHeader first, like the real ResponseWriter does
+func (c *cookieHardener) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// hardenCookie adds the Secure and HttpOnly attributes to a Set-Cookie value if they are missing
+func hardenCookie(cookie string) string {
+	hasSecure, hasHTTPOnly := false, false
+	for _, attr := range strings.Split(cookie, ";") {
+		switch strings.ToLower(strings.TrimSpace(attr)) {
+		case "secure":
+			hasSecure = true
+		case "httponly":
+			hasHTTPOnly = true
+		}
+	}
+
+	if !hasSecure {
+		cookie += "; Secure"
+	}
+	if !hasHTTPOnly {
+		cookie += "; HttpOnly"
+	}
+	return cookie
+}
+
+// auditRequests records an audit entry for every state-changing request (anything but GET, HEAD and OPTIONS)
+// with who made it and how it ended. Request bodies are never written to the audit log.
+func auditRequests(sink audit.Sink) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			err := sink.Record(audit.Entry{
+				Time:   time.Now(),
+				UserID: auditUserID(r),
+				Method: r.Method,
+				Path:   r.URL.Path,
+				Status: rec.status,
+			})
+			if err != nil {
+				log.Println("audit:", err)
+			}
+		})
+	}
+}
+
+// auditUserID returns the id of the logged in user, or "" for anonymous requests
+func auditUserID(r *http.Request) string {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(user.ID)
+}
+
+// SessionLoad loads the session of the visitor before the handler runs and saves it (with its cookie) afterwards
+func SessionLoad(next http.Handler) http.Handler {
+	return app.Session.LoadAndSave(next)
+}
+
+// authenticate loads the user whose id is in the session into the request context, so handlers and templates can
+// get them with auth.UserFromContext. It needs the session, so it runs after SessionLoad.
+// Requests without a logged in user just go on as anonymous.
+func authenticate(users auth.Users) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := app.Session.GetInt(r.Context(), auth.SessionUserID)
+			if id == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := users.UserByID(r.Context(), id)
+			switch {
+			case err != nil:
+				log.Println("authenticate:", err)
+			case user == nil:
+				// the user is gone, so the session is logged out instead of being looked up on every request
+				app.Session.Remove(r.Context(), auth.SessionUserID)
+			default:
+				r = r.WithContext(auth.NewContext(r.Context(), user))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// recoverPanic recovers from a panic in any handler, logs it with the stack trace and sends a 500 through render.RenderError:
+// API clients (paths under /api/ or asking for JSON) get the JSON error envelope, htmx a fragment, browsers the error.page.tmpl page.
+func recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler is used on purpose to abort a response, it must not be swallowed
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			id, _ := RequestIDFromContext(r.Context())
+			log.Printf("panic: %v request_id=%s\n%s", rec, id, debug.Stack())
+
+			// the visitor gets a friendly message, the panic value stays in the log since it can leak internals
+			render.RenderError(w, r, http.StatusInternalServerError, "Sorry, something went wrong on our side. Please try again in a moment.")
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiClientKey is the context key the authenticated api client id is stored under
+type apiClientKey struct{}
+
+// APIClientFromContext returns the id of the api client that was authenticated by APIKeyAuth
+func APIClientFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(apiClientKey{}).(string)
+	return id, ok
+}
+
+// APIKeyAuth protects the JSON api for machine clients. The key is read from "Authorization: Bearer <key>" or "X-API-Key",
+// checked by validator, and the client id it belongs to is stored in the request context. Anything else gets a 401.
+func APIKeyAuth(validator func(key string) (clientID string, ok bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("X-API-Key")
+			if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+				key = strings.TrimPrefix(auth, "Bearer ")
+			}
+
+			clientID, ok := "", false
+			if key != "" {
+				clientID, ok = validator(key)
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				render.RenderJSONError(w, http.StatusUnauthorized, "a valid API key is required")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiClientKey{}, clientID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// basicAuth protects a section (eg: /admin) with HTTP Basic auth. The password is checked against its bcrypt hash,
+// so the password itself is never stored, and the username is compared in constant time. Both are always checked,
+// so the response time doesn't tell if it was the username or the password that was wrong.
+// Without a configured username and hash every request is refused.
+func basicAuth(username, passwordHash string) func(http.Handler) http.Handler {
+	wantUser := sha256.Sum256([]byte(username))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if ok && username != "" && passwordHash != "" {
+				// hashing first makes both sides the same length, ConstantTimeCompare returns early on a different length
+				gotUser := sha256.Sum256([]byte(user))
+				userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:]) == 1
+				passOK := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(pass)) == nil
+				if userOK && passOK {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		})
+	}
+}
+
+// apiKeyValidator checks keys against the stored sha256 hashes (hex, by client id). The keys themselves are never stored,
+// and every hash is compared in constant time so the response time doesn't tell how close a guess was.
+func apiKeyValidator(hashes map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		sum := sha256.Sum256([]byte(key))
+		given := hex.EncodeToString(sum[:])
+
+		matched := ""
+		for clientID, stored := range hashes {
+			if subtle.ConstantTimeCompare([]byte(given), []byte(strings.ToLower(stored))) == 1 {
+				matched = clientID
+			}
+		}
+		return matched, matched != ""
+	}
+}
+
+// csrfProtect guards the forms against cross site request forgery. Every browser gets a random value in a cookie,
+// the pages put the token signed from it with key (csrf.Sign) in their forms (td.CSRFToken, {{csrfField .}}), and a
+// POST/PUT/PATCH/DELETE whose posted token doesn't match gets a 403. Another site can make the browser send our cookie,
+// but it can't read it, and without the key it couldn't make the token from it anyway.
+// The /api/ routes are skipped since they use API keys, not cookies.
+// Multipart forms must send the token in the X-CSRF-Token header, reading the field would consume the upload.
+func csrfProtect(key []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := ""
+			if cookie, err := r.Cookie(csrf.CookieName); err == nil && len(cookie.Value) == 64 {
+				value = cookie.Value
+			} else {
+				b := make([]byte, 32)
+				rand.Read(b)
+				value = hex.EncodeToString(b)
+				http.SetCookie(w, &http.Cookie{
+					Name:     csrf.CookieName,
+					Value:    value,
+					Path:     "/",
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			token := csrf.Sign(key, value)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			default:
+				if !strings.HasPrefix(r.URL.Path, "/api/") && !validCSRFToken(r, token) {
+					log.Printf("csrf: %s %s rejected, the token is missing or wrong", r.Method, r.URL.Path)
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(csrf.NewContext(r.Context(), token)))
+		})
+	}
+}
+
+// csrfKey is the key the CSRF tokens are signed with: the SessionSecret, which production requires. Without one
+// (in development) a random key is made, so the tokens of the forms still open stop working when the app restarts.
+func csrfKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// hostExemptPaths are the health checks, load balancers call them by the server's IP instead of by a host name
+var hostExemptPaths = []string{"/healthz", "/readyz"}
+
+// checkHost answers a request for a host that isn't in hosts (AllowedHosts) with a 400. A forged Host header would
+// otherwise end up in the https redirect and in any link built from it. The port is ignored, eg: "example.com"
+// allows "example.com:443". An empty list allows every host, development runs on localhost with any port.
+func checkHost(hosts []string) func(http.Handler) http.Handler {
+	allowed := map[string]bool{}
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed[host] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(allowed) == 0 || slices.Contains(hostExemptPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if !allowed[strings.ToLower(strings.TrimSuffix(host, "."))] {
+				log.Printf("host: %s %s rejected, %q is not an allowed host", r.Method, r.URL.Path, r.Host)
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validCSRFToken reports if the request carries the expected token, in the header or in the form
+func validCSRFToken(r *http.Request, expected string) bool {
+	sent := r.Header.Get(csrf.HeaderName)
+	if sent == "" && !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		sent = r.PostFormValue(csrf.FieldName)
+	}
+	return sent != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) == 1
+}
+
+// flagBucketCookie keeps a random id per browser, so a partially rolled out flag stays on (or off) for the same visitor
+const flagBucketCookie = "flag_bucket"
+
+// featureFlags puts the flag evaluator and the bucketing id of the visitor into the request context
+func featureFlags(f flags.Flags) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject := ""
+			if cookie, err := r.Cookie(flagBucketCookie); err == nil {
+				subject = cookie.Value
+			} else {
+				b := make([]byte, 16)
+				rand.Read(b)
+				subject = hex.EncodeToString(b)
+				http.SetCookie(w, &http.Cookie{
+					Name:     flagBucketCookie,
+					Value:    subject,
+					Path:     "/",
+					MaxAge:   365 * 24 * 60 * 60,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			ctx := flags.NewContext(flags.WithSubject(r.Context(), subject), f)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RealIP rewrites r.RemoteAddr to the real client IP when the request came through one of our trusted reverse proxies,
+// so logging, rate limiting and IP filters all see the same address. The forwarding headers of any other peer are ignored,
+// since a client could send them to pretend to be someone else. The rewritten RemoteAddr is only the IP, without a port.
+func RealIP(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	trusted := func(ip net.IP) bool {
+		for _, network := range trustedProxies {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			if peer := net.ParseIP(host); peer != nil && trusted(peer) {
+				if client := forwardedClientIP(r, trusted); client != "" {
+					r.RemoteAddr = client
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP reads X-Forwarded-For from right to left and returns the first address that is not one of our proxies,
+// that is the last hop we can trust. X-Real-IP is used when there is no X-Forwarded-For.
+func forwardedClientIP(r *http.Request, trusted func(net.IP) bool) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				return ""
+			}
+			if !trusted(ip) {
+				return ip.String()
+			}
+		}
+		return ""
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// parseTrustedProxies turns CIDRs (eg: 10.0.0.0/8) or single IPs into networks for RealIP
+func parseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
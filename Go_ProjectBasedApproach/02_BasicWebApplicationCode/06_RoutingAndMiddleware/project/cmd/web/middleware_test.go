@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/handlers"
+	"github.com/rahulrai17/porject/pkg/ratelimit"
+	"github.com/rahulrai17/porject/pkg/repository"
+	"github.com/rahulrai17/porject/pkg/requestid"
+)
+
+// panicking is a handler that always panics, like a handler with a bug
+var panicking = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("handler exploded: secret internals")
+})
+
+func TestRecoverPanic(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		htmx        bool
+		accept      string
+		contentType string
+		retarget    string
+		body        string
+	}{
+		{"page", "/home", false, "", "text/html; charset=utf-8", "", "Sorry, something went wrong"},
+		{"api", "/api/reservations", false, "", "application/json", "", `"status":500`},
+		{"accepts json", "/reservations", false, "application/json", "application/json", "", `"status":500`},
+		{"htmx", "/reservations", true, "", "text/html; charset=utf-8", "#errors", `<div class="error" role="alert">Sorry, something went wrong`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			recoverPanic(panicking).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rr.Header().Get("HX-Retarget"); got != tt.retarget {
+				t.Errorf("HX-Retarget = %q, want %q", got, tt.retarget)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q: %q", tt.body, rr.Body)
+			}
+			if strings.Contains(rr.Body.String(), "secret internals") {
+				t.Error("the panic value was sent to the client")
+			}
+			if tt.htmx && strings.Contains(rr.Body.String(), "<html") {
+				t.Errorf("htmx got a whole page: %q", rr.Body)
+			}
+		})
+	}
+}
+
+func TestRecoverPanicLogsStack(t *testing.T) {
+	out := captureLog(t)
+	recoverPanic(panicking).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/reservations", nil))
+
+	// the value the client doesn't see and the stack to find the bug are in the log
+	if !strings.Contains(out.String(), "panic: handler exploded: secret internals") {
+		t.Errorf("the panic value is not logged: %q", out)
+	}
+	if !strings.Contains(out.String(), "goroutine") {
+		t.Errorf("the stack is not logged: %q", out)
+	}
+}
+
+func TestRecoverPanicAbortHandler(t *testing.T) {
+	abort := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler to be panicked again", rec)
+		}
+	}()
+	recoverPanic(abort).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestDebugPanicPage(t *testing.T) {
+	old := handlers.Repo
+	handlers.NewHandlers(handlers.NewRepo(&app, repository.NewMemoryStore(), nil))
+	t.Cleanup(func() { handlers.NewHandlers(old) })
+	captureLog(t)
+
+	mux := routes(audit.NewMemorySink(), http.NotFoundHandler(), nil, auth.NewMemoryUsers())
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/panic", nil))
+
+	// the full router answers the panicking handler with the friendly error page of the app
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"<html", "Oops!", "Sorry, something went wrong"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, "/debug/panic was called") {
+		t.Error("the panic value was sent to the client")
+	}
+}
+
+// uuidPattern matches the version 4 UUIDs newUUID makes
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	// idEcho answers with the id the handler finds in the context
+	idEcho := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := RequestIDFromContext(r.Context())
+		io.WriteString(w, id)
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"none sent", "", false},
+		{"sent by a proxy", "abc-123", true},
+		{"with a space", "abc 123", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/home", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			requestID(idEcho).ServeHTTP(rr, req)
+
+			id := rr.Header().Get(requestid.Header)
+			if id != rr.Body.String() {
+				t.Errorf("the header has %q, the context %q", id, rr.Body)
+			}
+			if tt.keep && id != tt.incoming {
+				t.Errorf("id = %q, want the incoming %q unchanged", id, tt.incoming)
+			}
+			if !tt.keep && !uuidPattern.MatchString(id) {
+				t.Errorf("id = %q, want a new UUID", id)
+			}
+		})
+	}
+
+	if _, ok := RequestIDFromContext(context.Background()); ok {
+		t.Error("a context without an id reported one")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := rateLimit(ratelimit.New(1, 2, time.Minute))(ok)
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// the burst passes, the port doesn't make it another client
+	for _, addr := range []string{"192.0.2.1:1000", "192.0.2.1:2000"} {
+		if rr := send(addr); rr.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", addr, rr.Code)
+		}
+	}
+	rr := send("192.0.2.1:3000")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	if rr := send("192.0.2.2:1000"); rr.Code != http.StatusOK {
+		t.Errorf("another client: status = %d, want 200", rr.Code)
+	}
+}
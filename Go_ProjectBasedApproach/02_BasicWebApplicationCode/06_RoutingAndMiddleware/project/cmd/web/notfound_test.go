@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/handlers"
+	"github.com/rahulrai17/porject/pkg/repository"
+)
+
+func TestNotFoundPage(t *testing.T) {
+	old := handlers.Repo
+	handlers.NewHandlers(handlers.NewRepo(&app, repository.NewMemoryStore(), nil))
+	t.Cleanup(func() { handlers.NewHandlers(old) })
+	mux := routes(audit.NewMemorySink(), http.NotFoundHandler(), nil, auth.NewMemoryUsers())
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+	}{
+		{"page", "/no/such/page", "text/html; charset=utf-8", "<h1>Page not found</h1>"},
+		{"api", "/api/nothing", "application/json", `"status":404`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q: %q", tt.body, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// namedMiddleware is a middleware together with the name used by the ordering rules
+type namedMiddleware struct {
+	name string
+	mw   func(http.Handler) http.Handler
+}
+
+// orderRule says that the middleware named before must run before (be outside of) the one named after.
+// An empty before means after must be the very first (outermost) middleware.
+type orderRule struct {
+	before string
+	after  string
+}
+
+// middlewareOrderRules are the orderings that cause subtle bugs when they are broken
+var middlewareOrderRules = []orderRule{
+	// the logger prints the request id, so the id must exist before the logger runs
+	{before: "requestID", after: "LogRequest"},
+	// the logger must see the 500 of a recovered panic, a panic going through it would end without a log line
+	{before: "LogRequest", after: "recoverPanic"},
+	// a panic in the session, the handlers or any middleware between them must be caught
+	{before: "recoverPanic", after: "SessionLoad"},
+	// the logger should print the client's address, not the one of the proxy
+	{before: "RealIP", after: "LogRequest"},
+	// behind a proxy every client would share the proxy's bucket
+	{before: "RealIP", after: "rateLimit"},
+	// the cookie hardener must see the Set-Cookie headers of every later middleware and handler
+	{before: "secureCookies", after: "auditRequests"},
+	// the logged in user's id is read from the session
+	{before: "SessionLoad", after: "authenticate"},
+	// the audit log records the user, so the user must be known before it runs
+	{before: "authenticate", after: "auditRequests"},
+	// the https redirect is built from the Host header, so the host must be checked before
+	{before: "checkHost", after: "secureHeaders"},
+	// the CSRF cookie must get the Secure flag in production too
+	{before: "secureCookies", after: "csrfProtect"},
+	{before: "secureCookies", after: "SessionLoad"},
+}
+
+// checkMiddlewareOrder returns one message per broken rule. Rules about middlewares that are not in the chain are skipped.
+func checkMiddlewareOrder(chain []namedMiddleware, rules []orderRule) []string {
+	position := map[string]int{}
+	for i, m := range chain {
+		position[m.name] = i
+	}
+
+	var violations []string
+	for _, rule := range rules {
+		after, ok := position[rule.after]
+		if !ok {
+			continue
+		}
+
+		if rule.before == "" {
+			if after != 0 {
+				violations = append(violations, fmt.Sprintf("%s must be the first middleware, it is at position %d", rule.after, after))
+			}
+			continue
+		}
+
+		before, ok := position[rule.before]
+		if ok && before > after {
+			violations = append(violations, fmt.Sprintf("%s must run before %s", rule.before, rule.after))
+		}
+	}
+	return violations
+}
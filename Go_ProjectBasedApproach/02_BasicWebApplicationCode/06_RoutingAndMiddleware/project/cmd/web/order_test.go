@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+)
+
+// chainOf builds a chain with the names in order, the middleware themselves don't matter to the check
+func chainOf(names ...string) []namedMiddleware {
+	chain := make([]namedMiddleware, len(names))
+	for i, name := range names {
+		chain[i] = namedMiddleware{name: name, mw: recoverPanic}
+	}
+	return chain
+}
+
+func TestCheckMiddlewareOrder(t *testing.T) {
+	rules := []orderRule{
+		{before: "", after: "recoverPanic"},
+		{before: "requestID", after: "LogRequest"},
+		{before: "SessionLoad", after: "authenticate"},
+	}
+
+	tests := []struct {
+		name  string
+		chain []namedMiddleware
+		want  []string
+	}{
+		{"right order", chainOf("recoverPanic", "requestID", "LogRequest"), nil},
+		{
+			"wrong order",
+			chainOf("LogRequest", "recoverPanic", "requestID"),
+			[]string{"recoverPanic must be the first middleware, it is at position 1", "requestID must run before LogRequest"},
+		},
+		// authenticate is checked against SessionLoad only when both are in the chain
+		{"missing middleware", chainOf("recoverPanic", "authenticate"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkMiddlewareOrder(tt.chain, rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMiddlewareOrder(t *testing.T) {
+	// the chain the app really uses keeps every rule, so development starts without a warning
+	out := captureLog(t)
+	buildMiddleware(chi.NewRouter(), audit.NewMemorySink(), nil, auth.NewMemoryUsers())
+	if strings.Contains(out.String(), "WARNING middleware order") {
+		t.Errorf("the app's own chain breaks a rule: %s", out)
+	}
+}
+
+func TestMiddlewareExecutionOrder(t *testing.T) {
+	appChain := middlewareChain(audit.NewMemorySink(), nil, auth.NewMemoryUsers())
+
+	// every middleware of the app is swapped for one that records when the request passes it, in and out
+	var trace []string
+	chain := make([]namedMiddleware, len(appChain))
+	var names []string
+	for i, m := range appChain {
+		name := m.name
+		names = append(names, name)
+		chain[i] = namedMiddleware{name: name, mw: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace = append(trace, "in "+name)
+				next.ServeHTTP(w, r)
+				trace = append(trace, "out "+name)
+			})
+		}}
+	}
+	mux := chi.NewRouter()
+	useChain(mux, chain)
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) { trace = append(trace, "handler") })
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var want []string
+	for _, name := range names {
+		want = append(want, "in "+name)
+	}
+	want = append(want, "handler")
+	for i := len(names) - 1; i >= 0; i-- {
+		want = append(want, "out "+names[i])
+	}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("ran\n%q\nwant\n%q", trace, want)
+	}
+
+	// the order the request goes in: request id -> logger -> recover -> session
+	position := func(name string) int {
+		t.Helper()
+		i := slices.Index(names, name)
+		if i < 0 {
+			t.Fatalf("%s is not in the chain %q", name, names)
+		}
+		return i
+	}
+	if !(position("requestID") < position("LogRequest") && position("LogRequest") < position("recoverPanic") && position("recoverPanic") < position("SessionLoad")) {
+		t.Errorf("the chain is %q", names)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.5 ", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := RealIP(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RemoteAddr)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"trusted proxy", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"single trusted ip", "192.168.1.5:5000", map[string]string{"X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+		{"chain of proxies", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.9"}, "203.0.113.7"},
+		{"untrusted peer", "198.51.100.9:5000", map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.7"}, "198.51.100.9:5000"},
+		{"no header", "10.0.0.2:5000", nil, "10.0.0.2:5000"},
+		{"garbage header", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.0.0.2:5000"},
+		{"only proxies", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "10.0.0.3"}, "10.0.0.2:5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Body.String() != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", rr.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	if _, err := parseTrustedProxies([]string{"10.0.0.0/99"}); err == nil {
+		t.Error("an invalid CIDR was accepted")
+	}
+}
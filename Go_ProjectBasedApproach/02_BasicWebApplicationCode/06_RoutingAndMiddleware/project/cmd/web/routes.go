@@ -1,28 +1,130 @@
 package main
 
 import (
+	"log"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/flags"
 	"github.com/rahulrai17/porject/pkg/handlers"
+	"github.com/rahulrai17/porject/pkg/ratelimit"
 )
 
-func routes() http.Handler {
+func routes(auditSink audit.Sink, static http.Handler, trustedProxies []*net.IPNet, users auth.Users) http.Handler {
 	// Create a new router
 	mux := chi.NewRouter()
 
-	// Use the Recoverer middleware to recover from panics
-	mux.Use(middleware.Recoverer)
-	// Use a custom middleware to write to console, note: both these middlewares will be automatically applied in all routes since we are using routers here(eg: chi in this case).
-	mux.Use(writeToConsole)
-	// This will be called twice once before the api request and once after.
-	
-	// Define the route for the home page
-	mux.Get("/home", http.HandlerFunc(handlers.Repo.Home))
-	// Define the route for the about page
-	mux.Get("/about", http.HandlerFunc(handlers.Repo.About))
+	// Apply the middlewares, all of their ordering is in one place
+	buildMiddleware(mux, auditSink, trustedProxies, users)
+
+	// every route of the JSON api needs an API key
+	apiAuth := APIKeyAuth(apiKeyValidator(app.APIKeys))
+
+	// the admin section is its own sub router, every route in it needs the admin's username and password
+	adminAuth := basicAuth(app.AdminUser, app.AdminPasswordHash)
+
+	// Define the routes of all the handlers, each handler registers its own method and pattern next to its definition
+	var adminRoutes []handlers.Route
+	for _, route := range handlers.Routes() {
+		if route.Pattern == "/admin" || strings.HasPrefix(route.Pattern, "/admin/") {
+			adminRoutes = append(adminRoutes, route)
+			continue
+		}
+		middleware := route.Middleware
+		if strings.HasPrefix(route.Pattern, "/api/") {
+			middleware = append([]func(http.Handler) http.Handler{apiAuth}, middleware...)
+		}
+		mux.With(middleware...).Method(route.Method, route.Pattern, route.Handler)
+	}
+	mux.Route("/admin", func(r chi.Router) {
+		r.Use(adminAuth)
+		for _, route := range adminRoutes {
+			pattern := strings.TrimPrefix(route.Pattern, "/admin")
+			if pattern == "" {
+				pattern = "/"
+			}
+			r.With(route.Middleware...).Method(route.Method, pattern, route.Handler)
+		}
+	})
+	// Define the route for the static files (css, js, images)
+	mux.Handle("/static/*", static)
+	// Define the route for the request metrics (count, average latency and the latency histogram)
+	mux.Get("/metrics", appMetrics.Handler().ServeHTTP)
+
+	// Define the health checks for the load balancer / orchestrator: /healthz is "the process runs", /readyz is "it can serve traffic"
+	mux.Handle("/healthz", appHealth.LiveHandler())
+	mux.Handle("/readyz", appHealth.ReadyHandler())
+
+	// Define the page shown for every path that has no route, instead of chi's plain text 404
+	mux.NotFound(handlers.Repo.NotFound)
 
 	// Return the configured router
 	return mux
-}
\ No newline at end of file
+}
+
+// buildMiddleware applies every middleware to the mux in the order they run. The outer ones run first on the way in
+// and last on the way out: request id -> logger -> recover -> session, so a recovered panic is still logged
+// (as a 500 with its request id) and the session and everything after it are covered by the recover.
+// Note: all of them will be automatically applied in all routes since we are using routers here(eg: chi in this case).
+func buildMiddleware(mux *chi.Mux, auditSink audit.Sink, trustedProxies []*net.IPNet, users auth.Users) {
+	useChain(mux, middlewareChain(auditSink, trustedProxies, users))
+}
+
+// middlewareChain returns the middlewares of the app, outermost first
+func middlewareChain(auditSink audit.Sink, trustedProxies []*net.IPNet, users auth.Users) []namedMiddleware {
+	return []namedMiddleware{
+		// Use the real ip middleware first so everything after it sees the client's address instead of the proxy's
+		{"RealIP", RealIP(trustedProxies)},
+		// Use the request id middleware so every log line and outbound call of a request carries the same id
+		{"requestID", requestID},
+		// Use the access log middleware to log the method, path, status and duration of each request
+		{"LogRequest", LogRequest},
+		// Use the metrics middleware to time every request, the numbers can be seen on /metrics
+		{"collectMetrics", collectMetrics},
+		// Use our own recover middleware to recover from panics, it answers api requests with JSON and pages with HTML
+		{"recoverPanic", recoverPanic},
+		// Use the host middleware so only requests for our own hosts (AllowedHosts) are answered
+		{"checkHost", checkHost(app.AllowedHosts)},
+		// Use the security headers middleware on every response, in production it also sends http requests to https
+		{"secureHeaders", secureHeaders},
+		// Use the rate limit middleware so a single client can't flood the app, it needs the real ip
+		{"rateLimit", rateLimit(ratelimit.New(app.RateLimitRPS, app.RateLimitBurst, 10*time.Minute))},
+		// Use a custom middleware to write to console
+		{"writeToConsole", writeToConsole},
+		// Use the gzip middleware to compress the pages (and other text) for the browsers that support it
+		{"gzipResponse", gzipResponse},
+		// Use the cookie middleware so that in production every cookie gets the Secure and HttpOnly flags
+		{"secureCookies", secureCookies},
+		// Use the session middleware to load and save the session of every request
+		{"SessionLoad", SessionLoad},
+		// Use the auth middleware to find the logged in user, the audit log records who made the request
+		{"authenticate", authenticate(users)},
+		// Use the CSRF middleware so a form can only be posted from our own pages
+		{"csrfProtect", csrfProtect(csrfKey(app.SessionSecret))},
+		// Use the audit middleware so every state-changing request (POST, PUT, DELETE...) is recorded
+		{"auditRequests", auditRequests(auditSink)},
+		// Use the feature flags middleware so handlers and templates can ask if a feature is on for this visitor
+		{"featureFlags", featureFlags(flags.NewStatic(app.FeatureFlags))},
+		// Use the timeout middleware so a slow handler is answered with a 503 instead of keeping the visitor waiting
+		{"timeoutMiddleware", timeoutMiddleware(app.RequestTimeout)},
+	}
+}
+
+// useChain applies the chain to the mux in its order
+func useChain(mux *chi.Mux, chain []namedMiddleware) {
+	// a wrong order causes bugs that are hard to find, so in development we warn about it
+	if !app.InProduction {
+		for _, violation := range checkMiddlewareOrder(chain, middlewareOrderRules) {
+			log.Println("WARNING middleware order:", violation)
+		}
+	}
+
+	for _, m := range chain {
+		mux.Use(m.mw)
+	}
+}
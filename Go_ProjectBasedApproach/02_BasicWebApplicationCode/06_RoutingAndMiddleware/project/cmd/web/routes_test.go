@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/handlers"
+)
+
+func TestRoutesHasEveryRegisteredRoute(t *testing.T) {
+	mux, ok := routes(audit.NewMemorySink(), http.NotFoundHandler(), nil, auth.NewMemoryUsers()).(chi.Routes)
+	if !ok {
+		t.Fatal("routes did not return a chi router")
+	}
+
+	built := map[string]bool{}
+	err := chi.Walk(mux, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		// the admin sub router adds a trailing slash to its root
+		built[method+" "+strings.TrimSuffix(route, "/")] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered := handlers.Routes()
+	if len(registered) == 0 {
+		t.Fatal("no handler registered a route")
+	}
+	for _, route := range registered {
+		if !built[route.Method+" "+strings.TrimSuffix(route.Pattern, "/")] {
+			t.Errorf("%s %s is registered but not routed", route.Method, route.Pattern)
+		}
+	}
+}
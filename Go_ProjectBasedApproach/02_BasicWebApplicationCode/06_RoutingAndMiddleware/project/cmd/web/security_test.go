@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ok answers every request with a 200
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestCheckHost(t *testing.T) {
+	handler := checkHost([]string{"example.com", " WWW.Example.com"})(ok)
+
+	tests := []struct {
+		name   string
+		host   string
+		path   string
+		status int
+	}{
+		{"allowed", "example.com", "/home", http.StatusOK},
+		{"allowed with a port", "example.com:443", "/home", http.StatusOK},
+		{"case and trailing dot", "www.EXAMPLE.com.", "/home", http.StatusOK},
+		{"other host", "evil.com", "/home", http.StatusBadRequest},
+		{"subdomain not listed", "api.example.com", "/home", http.StatusBadRequest},
+		{"health check by ip", "10.0.0.5:8080", "/healthz", http.StatusOK},
+		{"readiness by ip", "10.0.0.5:8080", "/readyz", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("Host %q: status = %d, want %d", tt.host, rr.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestCheckHostNoHosts(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.Host = "localhost:8080"
+	rr := httptest.NewRecorder()
+	checkHost(nil)(ok).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("without AllowedHosts every host is allowed, got %d", rr.Code)
+	}
+}
+
+// tokenEcho answers with the CSRF token the handler (and so the page) gets
+var tokenEcho = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, csrf.Token(r.Context()))
+})
+
+func TestCSRFSignedToken(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	handler := csrfProtect(key)(tokenEcho)
+
+	// the first visit gets the cookie and the page the token signed from it
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/contact", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrf.CookieName {
+		t.Fatalf("got the cookies %v", cookies)
+	}
+	cookie := cookies[0]
+	token := rr.Body.String()
+	if token != csrf.Sign(key, cookie.Value) || token == cookie.Value {
+		t.Fatalf("the page got %q, want the signed cookie value", token)
+	}
+
+	post := func(h http.Handler, sent string) int {
+		form := url.Values{csrf.FieldName: {sent}}
+		req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if got := post(handler, token); got != http.StatusOK {
+		t.Errorf("the signed token: status = %d, want 200", got)
+	}
+	// knowing the cookie isn't enough, the token needs the key
+	if got := post(handler, cookie.Value); got != http.StatusForbidden {
+		t.Errorf("the raw cookie value: status = %d, want 403", got)
+	}
+	if got := post(handler, ""); got != http.StatusForbidden {
+		t.Errorf("no token: status = %d, want 403", got)
+	}
+	other := csrfProtect([]byte(strings.Repeat("o", 32)))(tokenEcho)
+	if got := post(other, token); got != http.StatusForbidden {
+		t.Errorf("a token of another key: status = %d, want 403", got)
+	}
+}
+
+func TestCSRFKey(t *testing.T) {
+	if got := string(csrfKey("the secret")); got != "the secret" {
+		t.Errorf("csrfKey = %q, want the secret", got)
+	}
+	// without a secret every start gets its own random key
+	a, b := csrfKey(""), csrfKey("")
+	if len(a) != 32 || string(a) == string(b) {
+		t.Errorf("got the keys %x and %x, want two random ones", a, b)
+	}
+}
+
+func TestCSRFProtectRequests(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	handler := csrfProtect(key)(tokenEcho)
+	cookie := &http.Cookie{Name: csrf.CookieName, Value: strings.Repeat("c", 64)}
+	token := csrf.Sign(key, cookie.Value)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		cookie bool
+		status int
+	}{
+		{"get needs no token", http.MethodGet, "/contact", "", true, http.StatusOK},
+		{"token in the header", http.MethodPost, "/contact", token, true, http.StatusOK},
+		{"wrong token in the header", http.MethodPost, "/contact", "nope", true, http.StatusForbidden},
+		{"delete without a token", http.MethodDelete, "/contact", "", true, http.StatusForbidden},
+		{"no cookie yet", http.MethodPost, "/contact", token, false, http.StatusForbidden},
+		{"api is not checked", http.MethodPost, "/api/reservations", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(csrf.HeaderName, tt.header)
+			}
+			if tt.cookie {
+				req.AddCookie(cookie)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && tt.cookie && rr.Body.String() != token {
+				t.Errorf("the handler got the token %q, want %q", rr.Body, token)
+			}
+		})
+	}
+}
+
+func TestSecureHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		production bool
+		proto      string
+		status     int
+		location   string
+	}{
+		{"production over http", true, "http", http.StatusPermanentRedirect, "https://example.com/rooms?id=1"},
+		{"production without the proxy header", true, "", http.StatusPermanentRedirect, "https://example.com/rooms?id=1"},
+		{"production over https", true, "https", http.StatusOK, ""},
+		{"development over http", false, "http", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSetting(t, &app.InProduction, tt.production)
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/rooms?id=1", nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			rr := httptest.NewRecorder()
+			secureHeaders(ok).ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if got := rr.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			// the headers are set on every response, the redirect included
+			want := map[string]string{
+				"Strict-Transport-Security": fmt.Sprintf("max-age=%d; includeSubDomains", hstsMaxAge),
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+			}
+			for header, value := range want {
+				if got := rr.Header().Get(header); got != value {
+					t.Errorf("%s = %q, want %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		hash     string
+		user     string
+		pass     string
+		noHeader bool
+		status   int
+	}{
+		{"right credentials", "admin", string(hash), "admin", "s3cret", false, http.StatusOK},
+		{"wrong password", "admin", string(hash), "admin", "guess", false, http.StatusUnauthorized},
+		{"wrong user", "admin", string(hash), "root", "s3cret", false, http.StatusUnauthorized},
+		{"no header", "admin", string(hash), "", "", true, http.StatusUnauthorized},
+		{"nothing configured", "", "", "", "", false, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if !tt.noHeader {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			rr := httptest.NewRecorder()
+			basicAuth(tt.username, tt.hash)(ok).ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			challenge := rr.Header().Get("WWW-Authenticate")
+			if tt.status == http.StatusUnauthorized && !strings.HasPrefix(challenge, `Basic realm="admin"`) {
+				t.Errorf("WWW-Authenticate = %q, want the Basic challenge", challenge)
+			}
+		})
+	}
+}
+
+func TestAdminRoutesNeedAuth(t *testing.T) {
+	mux := routes(audit.NewMemorySink(), http.NotFoundHandler(), nil, auth.NewMemoryUsers())
+
+	// every route under /admin is behind basic auth, the sub router doesn't leave one open
+	for _, path := range []string{"/admin", "/admin/"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", path, rr.Code)
+		}
+	}
+}
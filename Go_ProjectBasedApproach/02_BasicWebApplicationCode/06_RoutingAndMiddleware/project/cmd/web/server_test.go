@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// waitForServer retries until the server at addr accepts connections
+func waitForServer(t *testing.T, addr string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		c, err := net.Dial("tcp", addr)
+		if err == nil {
+			c.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the server at %s never started", addr)
+}
+
+func TestRunServerShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	addr := freeAddr(t)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- runServer(srv, ctx) }()
+	waitForServer(t, addr)
+
+	// a request is in flight when the signal comes in
+	type response struct {
+		body string
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{string(body), err}
+	}()
+	<-started
+	cancel()
+
+	// the server waits for it instead of cutting it off
+	select {
+	case err := <-result:
+		t.Fatalf("runServer returned %v while a request was in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	if r := <-responses; r.err != nil || r.body != "done" {
+		t.Errorf("in flight request got %q %v, want it to finish", r.body, r.err)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("runServer = %v, want nil after a graceful shutdown", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("runServer did not return after the shutdown")
+	}
+
+	// new connections are refused now
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("the server still accepts connections")
+	}
+}
+
+func TestRunServerListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// the port is taken, runServer returns at once instead of waiting for a signal
+	err = runServer(&http.Server{Addr: ln.Addr().String()}, context.Background())
+	if err == nil {
+		t.Error("want an error for a port that is in use")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/config"
+)
+
+// sessionServer runs a session manager with the timeouts, /put stores a value and /get answers with it
+func sessionServer(t *testing.T, idle, lifetime time.Duration) (*httptest.Server, *http.Client) {
+	t.Helper()
+	session := newSessionManager(&config.AppConfig{SessionIdleTimeout: idle, SessionLifetime: lifetime})
+	if session.IdleTimeout != idle || session.Lifetime != lifetime {
+		t.Fatalf("got idle %v lifetime %v, want %v %v", session.IdleTimeout, session.Lifetime, idle, lifetime)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", func(w http.ResponseWriter, r *http.Request) {
+		session.Put(r.Context(), "value", "here")
+	})
+	mux.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, session.GetString(r.Context(), "value"))
+	})
+	ts := httptest.NewServer(session.LoadAndSave(mux))
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ts.Client()
+	client.Jar = jar
+	return ts, client
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	ts, client := sessionServer(t, 200*time.Millisecond, time.Hour)
+	get(t, client, ts.URL+"/put")
+
+	// every use pushes the idle deadline back
+	for range 3 {
+		time.Sleep(100 * time.Millisecond)
+		if got := get(t, client, ts.URL+"/get"); got != "here" {
+			t.Fatalf("the session expired while it was used, got %q", got)
+		}
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := get(t, client, ts.URL+"/get"); got != "" {
+		t.Errorf("the idle session is still there: %q", got)
+	}
+}
+
+func TestSessionLifetime(t *testing.T) {
+	ts, client := sessionServer(t, time.Hour, 300*time.Millisecond)
+	get(t, client, ts.URL+"/put")
+
+	time.Sleep(100 * time.Millisecond)
+	if got := get(t, client, ts.URL+"/get"); got != "here" {
+		t.Fatalf("the session expired too early, got %q", got)
+	}
+
+	// being used all the time doesn't keep it alive past its lifetime
+	deadline := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		get(t, client, ts.URL+"/get")
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := get(t, client, ts.URL+"/get"); got != "" {
+		t.Errorf("the session outlived its lifetime: %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rahulrai17/porject/pkg/audit"
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/config"
+)
+
+// serveStatic returns the response of the handler for path
+func serveStatic(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestStaticHandlerMissingDir(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "static")
+
+	out := captureLog(t)
+	h, err := staticHandler(&config.AppConfig{StaticDir: missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "WARNING static directory") || !strings.Contains(out.String(), missing) {
+		t.Errorf("no warning for the missing dir: %q", out)
+	}
+	if rr := serveStatic(t, h, "/static/app.css"); rr.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rr.Code)
+	}
+
+	_, err = staticHandler(&config.AppConfig{StaticDir: missing, RequireStatic: true})
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("err = %v, want the missing dir to stop the start", err)
+	}
+}
+
+func TestStaticHandlerDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureLog(t)
+	h, err := staticHandler(&config.AppConfig{StaticDir: dir, RequireStatic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("warned about an existing dir: %q", out)
+	}
+	if rr := serveStatic(t, h, "/static/app.css"); rr.Code != http.StatusOK || rr.Body.String() != "body{}" {
+		t.Errorf("got %d %q, want the file", rr.Code, rr.Body)
+	}
+}
+
+func TestStaticThroughRoutes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test.css"), []byte("h1{color:red}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := staticHandler(&config.AppConfig{StaticDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the router mounts the handler at /static/, the file comes back as css
+	rr := serveStatic(t, routes(audit.NewMemorySink(), h, nil, auth.NewMemoryUsers()), "/static/test.css")
+	if rr.Code != http.StatusOK || rr.Body.String() != "h1{color:red}" {
+		t.Fatalf("got %d %q, want the file", rr.Code, rr.Body)
+	}
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/css") {
+		t.Errorf("Content-Type = %q, want text/css", got)
+	}
+}
+
+func TestStaticHandlerEmbedded(t *testing.T) {
+	fsys := fstest.MapFS{"js/app.js": {Data: []byte("console.log(1)")}}
+
+	// the embedded files are used, the missing dir on disk doesn't matter
+	out := captureLog(t)
+	h, err := staticHandler(&config.AppConfig{StaticFS: fsys, StaticDir: filepath.Join(t.TempDir(), "nope"), RequireStatic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("warned although the files are embedded: %q", out)
+	}
+	rr := serveStatic(t, h, "/static/js/app.js")
+	if rr.Code != http.StatusOK || rr.Body.String() != "console.log(1)" {
+		t.Errorf("got %d %q, want the embedded file", rr.Code, rr.Body)
+	}
+	if rr := serveStatic(t, h, "/static/js/other.js"); rr.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rr.Code)
+	}
+}
+
+func TestStaticETags(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.css")
+	if err := os.WriteFile(file, []byte("body{color:red}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := staticHandler(&config.AppConfig{StaticDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := serveStatic(t, h, "/static/app.css")
+	etag := first.Header().Get("ETag")
+	// a strong validator: quoted, without the W/ of a weak one
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("got %d with ETag %q, want a strong ETag", first.Code, etag)
+	}
+	if again := serveStatic(t, h, "/static/app.css").Header().Get("ETag"); again != etag {
+		t.Errorf("ETag changed from %s to %s", etag, again)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("conditional request got %d with %d bytes, want an empty 304", rr.Code, rr.Body.Len())
+	}
+
+	// the same bytes in another deploy (another dir, another modtime) keep the ETag
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "app.css"), []byte("body{color:red}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := staticHandler(&config.AppConfig{StaticDir: other})
+	if got := serveStatic(t, h2, "/static/app.css").Header().Get("ETag"); got != etag {
+		t.Errorf("identical file got ETag %s, want %s", got, etag)
+	}
+
+	// a changed file gets a new one
+	if err := os.WriteFile(file, []byte("body{color:blue}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := serveStatic(t, h, "/static/app.css").Header().Get("ETag"); got == etag {
+		t.Error("the changed file kept its ETag")
+	}
+}
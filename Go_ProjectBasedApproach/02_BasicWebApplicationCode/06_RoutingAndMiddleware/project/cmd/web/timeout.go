@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/render"
+)
+
+// timeoutMiddleware gives every request d to finish. The request context is cancelled after d, so a handler that passes
+// r.Context() on (to the database, outbound calls, a select) can stop early, and when it ran out of time without
+// answering the visitor gets a 503 Service Unavailable through render.RenderError (the error page, the JSON error or an htmx fragment).
+// Like chi's middleware.Timeout the handler isn't interrupted: one that ignores its context runs until it is done.
+// A d of 0 turns the timeout off.
+func timeoutMiddleware(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			// the handler already answered (maybe with its own error), that answer is kept
+			if tw.wroteHeader || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+
+			render.RenderError(w, r, http.StatusServiceUnavailable, "Sorry, this is taking too long. Please try again in a moment.")
+		})
+	}
+}
+
+// timeoutWriter remembers if the handler started its response, a started response can't be replaced by the error page
+type timeoutWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader marks the response as started
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends an implicit 200, like the real ResponseWriter does
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.wroteHeader = true
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush passes a flush on, so streaming handlers keep working behind the timeout
+func (tw *timeoutWriter) Flush() {
+	tw.wroteHeader = true
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.NewResponseController reach the real ResponseWriter
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	// waits for its context like a database call would
+	waiting := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			io.WriteString(w, "too late")
+		}
+	})
+	// ignores its context, it only notices the time is up when it is done
+	sleeping := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	// answers in time
+	quick := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "done")
+	})
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		handler http.Handler
+		status  int
+		body    string
+	}{
+		{"handler watching its context", 20 * time.Millisecond, waiting, http.StatusServiceUnavailable, "Sorry, this is taking too long"},
+		{"handler ignoring its context", 20 * time.Millisecond, sleeping, http.StatusServiceUnavailable, "Sorry, this is taking too long"},
+		{"in time", time.Second, quick, http.StatusOK, "done"},
+		{"turned off", 0, sleeping, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			start := time.Now()
+			timeoutMiddleware(tt.timeout)(tt.handler).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/reservations", nil))
+
+			if time.Since(start) > time.Second {
+				t.Errorf("the request took %v", time.Since(start))
+			}
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q: %q", tt.body, rr.Body)
+			}
+		})
+	}
+}
+
+func TestTimeoutKeepsStartedResponse(t *testing.T) {
+	// the handler answered before it ran out of time, the answer can't be swapped for the error page anymore
+	started := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		<-r.Context().Done()
+	})
+	rr := httptest.NewRecorder()
+	timeoutMiddleware(10*time.Millisecond)(started).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusAccepted || rr.Body.Len() != 0 {
+		t.Errorf("got %d %q, want the handler's 202", rr.Code, rr.Body)
+	}
+}
@@ -2,4 +2,21 @@ module github.com/rahulrai17/porject
 
 go 1.23.1
 
-require github.com/go-chi/chi/v5 v5.2.0
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/alexedwards/scs/v2 v2.8.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/go-chi/chi/v5 v5.2.0
+	github.com/jackc/pgx/v5 v5.6.0
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sink.Record(Entry{Time: at, UserID: "7", Method: "POST", Path: "/reservations", Status: 303})
+	sink.Record(Entry{Time: at, Method: "DELETE", Path: "/reservations/1", Status: 204})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per entry: %q", len(lines), data)
+	}
+	want := `{"time":"2024-05-01T12:00:00Z","user_id":"7","method":"POST","path":"/reservations","status":303}`
+	if lines[0] != want {
+		t.Errorf("line = %s, want %s", lines[0], want)
+	}
+
+	// reopening appends instead of truncating
+	sink, err = NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Record(Entry{Time: at, Method: "PUT", Path: "/x", Status: 200})
+	sink.Close()
+	data, _ = os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("got %d lines after reopening, want 3", n)
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// userKey is the context key the logged in user is stored under
+type userKey struct{}
+
+// NewContext returns a copy of ctx carrying the logged in user, this is what the auth middleware calls
+func NewContext(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the logged in user, ok is false for anonymous requests
+func UserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(userKey{}).(*models.User)
+	return user, ok && user != nil
+}
+
+// SessionUserID is the key the logged in user's id is stored under in the visitor's session (app.Session),
+// the session cookie is the only cookie a logged in visitor needs
+const SessionUserID = "user_id"
+
+// Login logs the visitor of the request context in as the user. The session gets a new token first: whoever knew
+// the token from before the login (eg: one planted in the visitor's browser, session fixation) can't use it after.
+func Login(ctx context.Context, session *scs.SessionManager, userID int) error {
+	err := session.RenewToken(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: login: %w", err)
+	}
+	session.Put(ctx, SessionUserID, userID)
+	return nil
+}
+
+// Logout logs the visitor out. The rest of the session (eg: a flash message saying they logged out) is kept,
+// under a new token like on Login.
+func Logout(ctx context.Context, session *scs.SessionManager) error {
+	err := session.RenewToken(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: logout: %w", err)
+	}
+	session.Remove(ctx, SessionUserID)
+	return nil
+}
+
+// Users finds the logged in user by the id in the session, it returns a nil user (and no error) for an unknown id
+type Users interface {
+	UserByID(ctx context.Context, id int) (*models.User, error)
+}
+
+// MemoryUsers keeps the users in memory, they are lost when the app restarts
+type MemoryUsers struct {
+	mu    sync.RWMutex
+	users map[int]*models.User
+}
+
+// NewMemoryUsers creates an empty MemoryUsers
+func NewMemoryUsers() *MemoryUsers {
+	return &MemoryUsers{users: map[int]*models.User{}}
+}
+
+// Add adds the user (or replaces the one with the same id)
+func (s *MemoryUsers) Add(user *models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// Remove removes the user, a session still holding their id is anonymous from then on
+func (s *MemoryUsers) Remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, id)
+}
+
+// UserByID returns the user with the id, or nil
+func (s *MemoryUsers) UserByID(ctx context.Context, id int) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[id], nil
+}
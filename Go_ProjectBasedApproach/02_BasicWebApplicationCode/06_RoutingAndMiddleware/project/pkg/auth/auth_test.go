@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestUserFromContext(t *testing.T) {
+	user := &models.User{ID: 7, Name: "Rahul"}
+
+	got, ok := UserFromContext(NewContext(context.Background(), user))
+	if !ok || got != user {
+		t.Errorf("got %v %v, want the user", got, ok)
+	}
+
+	got, ok = UserFromContext(context.Background())
+	if ok || got != nil {
+		t.Errorf("anonymous request: got %v %v, want nil false", got, ok)
+	}
+
+	// a nil user put into the context is still anonymous
+	_, ok = UserFromContext(NewContext(context.Background(), nil))
+	if ok {
+		t.Error("a nil user counts as logged in")
+	}
+}
+
+func TestMemoryUsers(t *testing.T) {
+	users := NewMemoryUsers()
+	ctx := context.Background()
+	users.Add(&models.User{ID: 7, Name: "Rahul"})
+
+	user, err := users.UserByID(ctx, 7)
+	if err != nil || user == nil || user.Name != "Rahul" {
+		t.Errorf("got %v %v, want Rahul", user, err)
+	}
+
+	user, err = users.UserByID(ctx, 8)
+	if err != nil || user != nil {
+		t.Errorf("unknown id: got %v %v, want nil nil", user, err)
+	}
+
+	users.Remove(7)
+	user, _ = users.UserByID(ctx, 7)
+	if user != nil {
+		t.Errorf("removed user still found: %v", user)
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// loginServer has the routes a visitor goes through, /whoami answers with the user id and the "note" in the session
+func loginServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	session := scs.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/visit", func(w http.ResponseWriter, r *http.Request) {
+		session.Put(r.Context(), "note", "kept")
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if err := Login(r.Context(), session, 7); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if err := Logout(r.Context(), session); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strconv.Itoa(session.GetInt(r.Context(), SessionUserID))+" "+session.GetString(r.Context(), "note"))
+	})
+	ts := httptest.NewServer(session.LoadAndSave(mux))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// visit requests the path with the session cookie (if any) and returns the body and the session cookie
+// the response set, or the one that was sent when it set none
+func visit(t *testing.T, ts *httptest.Server, path string, cookie *http.Cookie) (string, *http.Cookie) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == "session" {
+			cookie = c
+		}
+	}
+	return string(body), cookie
+}
+
+func TestLoginRenewsToken(t *testing.T) {
+	ts := loginServer(t)
+
+	_, before := visit(t, ts, "/visit", nil)
+	if before == nil {
+		t.Fatal("no session cookie before the login")
+	}
+
+	_, after := visit(t, ts, "/login", before)
+	if after.Value == before.Value {
+		t.Fatal("the session token didn't change on login")
+	}
+
+	if got, _ := visit(t, ts, "/whoami", after); got != "7 kept" {
+		t.Errorf("with the new token: %q, want the user and the rest of the session", got)
+	}
+	// the token from before the login is worth nothing now
+	if got, _ := visit(t, ts, "/whoami", before); got != "0 " {
+		t.Errorf("with the old token: %q, want an empty session", got)
+	}
+}
+
+func TestLogoutRenewsToken(t *testing.T) {
+	ts := loginServer(t)
+
+	_, cookie := visit(t, ts, "/visit", nil)
+	_, loggedIn := visit(t, ts, "/login", cookie)
+	_, loggedOut := visit(t, ts, "/logout", loggedIn)
+
+	if loggedOut.Value == loggedIn.Value {
+		t.Fatal("the session token didn't change on logout")
+	}
+	if got, _ := visit(t, ts, "/whoami", loggedOut); got != "0 kept" {
+		t.Errorf("after the logout: %q, want no user but the rest of the session", got)
+	}
+	if got, _ := visit(t, ts, "/whoami", loggedIn); got != "0 " {
+		t.Errorf("the logged in token still works: %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+// Package calc is the Calculator of the error checking lessons, used by the /calc page
+package calc
+
+import "errors"
+
+// ErrDivideByZero is returned when the divisor is 0, check for it with errors.Is(err, ErrDivideByZero)
+var ErrDivideByZero = errors.New("cannot divide by zero")
+
+// Operation is one calculation done by a Calculator, failed ones are recorded too
+type Operation struct {
+	Name     string    // "add", "subtract", "multiply" or "divide"
+	Operands []float64 // The numbers the operation was done with
+	Result   float64   // The result, 0 when the operation failed
+	Err      error     // Why the operation failed, nil when it worked
+}
+
+// Calculator does basic math and remembers every operation it did
+type Calculator struct {
+	history []Operation
+}
+
+// record appends the operation to the history and returns its result and error
+func (c *Calculator) record(name string, x, y, result float64, err error) (float64, error) {
+	c.history = append(c.history, Operation{
+		Name:     name,
+		Operands: []float64{x, y},
+		Result:   result,
+		Err:      err,
+	})
+	return result, err
+}
+
+// Add returns x + y
+func (c *Calculator) Add(x, y float64) float64 {
+	result, _ := c.record("add", x, y, x+y, nil)
+	return result
+}
+
+// Subtract returns x - y
+func (c *Calculator) Subtract(x, y float64) float64 {
+	result, _ := c.record("subtract", x, y, x-y, nil)
+	return result
+}
+
+// Multiply returns x * y
+func (c *Calculator) Multiply(x, y float64) float64 {
+	result, _ := c.record("multiply", x, y, x*y, nil)
+	return result
+}
+
+// Divide returns x / y, or ErrDivideByZero when y is 0 (the failed attempt is still in the history)
+func (c *Calculator) Divide(x, y float64) (float64, error) {
+	if y == 0 {
+		return c.record("divide", x, y, 0, ErrDivideByZero)
+	}
+	return c.record("divide", x, y, x/y, nil)
+}
+
+// History returns a copy of the operations done so far, the oldest first
+func (c *Calculator) History() []Operation {
+	history := make([]Operation, len(c.history))
+	copy(history, c.history)
+	return history
+}
+
+// Reset forgets every operation
+func (c *Calculator) Reset() {
+	c.history = nil
+}
@@ -1,15 +1,101 @@
 package config
 
-import "html/template"
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/models"
+)
 
 // This is a file that is accessed by whole project. This is also know as (application wide configuration).
 // We only add most useful and valid configuration here.
 // This file is imported by whole application
 // Remenber this should be program in a way that this doesn't import anything from the application.
 // It is only allowed to import form standard libraries only (This precaution is taken to avoid the import cycle. Import cycle doesn't allow the application to be compiled).
+// The only exception is the models package, which only imports the forms package (and that one nothing from the application) so it can never create a cycle.
+// Outside libraries like the session manager are fine too, they can't import us back.
 
 // AppConfig holds the application config
 type AppConfig struct{
-  UseCache bool
+  UseCache bool // No longer decides anything: production always uses TemplateCache and development rebuilds (on every request, or on a change while the template watcher runs).
+  InProduction bool // true when the app runs in production, this turns on the stricter (secure) behaviour.
+  Port string // The port the server listens on, eg: "8080".
   TemplateCache map[string]*template.Template
+  InfoLog *slog.Logger // Where the normal events are logged (text in development, JSON in production).
+  ErrorLog *slog.Logger // Where the errors are logged, nil means the default slog logger.
+  Session *scs.SessionManager // The session of every visitor, eg: for the flash messages shown after a redirect.
+  SessionIdleTimeout time.Duration // A session nobody used for this long expires.
+  SessionLifetime time.Duration // A session expires this long after it was created, even if it is used all the time.
+  SessionSecret string // Secret used to sign sessions and tokens, must be long and random in production.
+  AllowedHosts []string // Hosts the app answers for (eg: example.com), required in production.
+  TrustedProxies []string // Reverse proxies (IPs or CIDRs) whose X-Forwarded-For / X-Real-IP headers are believed.
+  BaseURL string // The public URL of the site (eg: https://example.com), used to build absolute links like the sitemap entries.
+  LogSkipPaths []string // Paths that are not written to the access log unless they fail. An entry ending with "/" matches as a prefix.
+  TemplateDirs []string // Directories the templates are loaded from, in order. Defaults to ../../templates.
+  PageGlob string // Pattern of the page templates in each template dir, defaults to *.page.tmpl.
+  LayoutGlob string // Pattern of the layout templates, defaults to *.layout.tmpl.
+  DefaultLayout string // Layout file of the pages without an entry in PageLayouts, defaults to base.layout.tmpl.
+  PageLayouts map[string]string // The layout file of the pages that don't use the default one, eg: "dashboard.page.tmpl": "admin.layout.tmpl".
+  PartialGlob string // Pattern of the partial templates, defaults to partials/*.partial.tmpl.
+  StrictTemplates bool // Fail the template cache build when two directories contain a template with the same name.
+  StaticDir string // Directory the /static/ files are served from.
+  TemplateFS fs.FS // Embedded templates (eg: an embed.FS), used instead of TemplateDirs when set.
+  StaticFS fs.FS // Embedded static files (eg: an embed.FS), used instead of StaticDir when set.
+  RequireStatic bool // Refuse to start when StaticDir doesn't exist, instead of only logging a warning.
+  StrictMissingKeys bool // Make a missing map key in a template a render error instead of "<no value>".
+  MinifyHTML bool // Collapse the whitespace of the rendered pages before they are sent.
+  MaxResponseBytes int // The largest page a template may render, bigger ones fail with a 500. 0 means no limit.
+  ConditionalPages []string // Page templates whose output only depends on the template files, they get Last-Modified and 304 support (unless the page shows data of the request, eg: a flash message).
+  DSN string // The database connection string, when empty the app keeps its data in memory.
+  DBMaxOpenConns int // Maximum number of open connections in the database pool.
+  DBMaxIdleConns int // Maximum number of idle connections kept in the pool.
+  DBConnMaxLifetime time.Duration // How long a connection can be reused before it is closed.
+  DefaultOpenGraph models.OpenGraph // Site wide og: meta tags used when a handler doesn't set its own.
+  DefaultTemplateData *models.TemplateData // App wide template values (eg: site name) merged into every render, the handler's own values win.
+  RateLimitRPS float64 // How many requests per second one client IP may make on average.
+  RateLimitBurst int // How many requests one client IP may make at once before the rate limit kicks in.
+  RequestTimeout time.Duration // The longest a handler may take before the visitor gets a 503, 0 means no limit.
+  MaxConnsPerIP int // Most connections one client IP can have open at once, 0 means no limit.
+  MaxConns int // Most connections the server keeps open at once, 0 means no limit.
+  FeatureFlags map[string]int // Feature flag name => percentage of users that get it (0 off, 100 everyone).
+  APIKeys map[string]string // The api clients, client id => hex sha256 hash of its API key.
+  AdminUser string // Username of the /admin section.
+  AdminPasswordHash string // bcrypt hash of the /admin password, without it (or AdminUser) /admin refuses everybody.
+  HTTPClientTimeout time.Duration // The longest an outbound HTTP call may take.
+  AuditLogPath string // File the audit log of state-changing requests is appended to, when empty the entries are kept in memory.
+}
+
+// minSessionSecretLength is the shortest session secret accepted in production
+const minSessionSecretLength = 32
+
+// RequireForProduction checks that everything production depends on is configured, and returns all the problems at once.
+// In development it always returns nil, so the app can run with the defaults.
+func (c *AppConfig) RequireForProduction() error {
+	if !c.InProduction {
+		return nil
+	}
+
+	var errs []error
+	if len(c.SessionSecret) < minSessionSecretLength {
+		errs = append(errs, fmt.Errorf("SessionSecret must be at least %d characters", minSessionSecretLength))
+	}
+	if c.BaseURL == "" || strings.Contains(c.BaseURL, "localhost") {
+		errs = append(errs, errors.New("BaseURL must be set to the public URL of the site"))
+	} else if !strings.HasPrefix(c.BaseURL, "https://") {
+		errs = append(errs, errors.New("BaseURL must use https"))
+	}
+	if len(c.AllowedHosts) == 0 {
+		errs = append(errs, errors.New("AllowedHosts must list at least one host"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config not ready for production: %w", errors.Join(errs...))
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// productionConfig is a complete production config, the tests break one field at a time
+func productionConfig() *AppConfig {
+	return &AppConfig{
+		InProduction:  true,
+		SessionSecret: strings.Repeat("s", minSessionSecretLength),
+		BaseURL:       "https://example.com",
+		AllowedHosts:  []string{"example.com"},
+	}
+}
+
+func TestRequireForProduction(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *AppConfig)
+		errors []string
+	}{
+		{"complete", func(c *AppConfig) {}, nil},
+		{"development with nothing set", func(c *AppConfig) { *c = AppConfig{} }, nil},
+		{"no secret", func(c *AppConfig) { c.SessionSecret = "" }, []string{"SessionSecret must be at least 32 characters"}},
+		{"short secret", func(c *AppConfig) { c.SessionSecret = "secret" }, []string{"SessionSecret"}},
+		{"localhost", func(c *AppConfig) { c.BaseURL = "https://localhost:8080" }, []string{"BaseURL must be set"}},
+		{"http", func(c *AppConfig) { c.BaseURL = "http://example.com" }, []string{"BaseURL must use https"}},
+		{"no hosts", func(c *AppConfig) { c.AllowedHosts = nil }, []string{"AllowedHosts"}},
+		{"everything missing", func(c *AppConfig) { *c = AppConfig{InProduction: true} }, []string{"SessionSecret", "BaseURL", "AllowedHosts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := productionConfig()
+			tt.change(c)
+			err := c.RequireForProduction()
+
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Errorf("want no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			// every problem is reported at once
+			for _, want := range tt.errors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
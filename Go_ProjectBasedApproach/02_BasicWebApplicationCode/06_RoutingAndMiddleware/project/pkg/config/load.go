@@ -0,0 +1,241 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultConfigFile is the file Load reads when no path is given, it doesn't have to exist
+const DefaultConfigFile = "config.json"
+
+// the values used when neither the config file nor the environment sets them
+const (
+	defaultPort        = "8080"
+	defaultTemplateDir = "../../templates"
+	defaultStaticDir   = "../../static"
+)
+
+// fileConfig is the shape of the config file, every field is optional, eg:
+//
+//	{"port": "9090", "in_production": true, "base_url": "https://example.com", "allowed_hosts": ["example.com"]}
+type fileConfig struct {
+	Port           string            `json:"port"`
+	InProduction   *bool             `json:"in_production"`
+	TemplateDir    string            `json:"template_dir"`
+	StaticDir      string            `json:"static_dir"`
+	BaseURL        string            `json:"base_url"`
+	SessionSecret  string            `json:"session_secret"`
+	AllowedHosts   []string          `json:"allowed_hosts"`
+	DSN            string            `json:"database_url"`
+	AuditLogPath   string            `json:"audit_log"`
+	AdminUser      string            `json:"admin_user"`
+	AdminPassword  string            `json:"admin_password_hash"`
+	TrustedProxies []string          `json:"trusted_proxies"`
+	APIKeys        map[string]string `json:"api_keys"`
+}
+
+// Load builds the AppConfig from three places, each one overriding the one before it:
+// the defaults, the JSON config file at path and the environment variables (PORT, IN_PRODUCTION, TEMPLATE_DIR,
+// STATIC_DIR, BASE_URL, SESSION_SECRET, ALLOWED_HOSTS, DATABASE_URL, AUDIT_LOG, ADMIN_USER, ADMIN_PASSWORD_HASH,
+// TRUSTED_PROXIES, API_KEYS). The lists are comma separated, API_KEYS is "client:sha256hex,other:sha256hex".
+// An empty path reads DefaultConfigFile when it exists, a path that is given must exist.
+// Everything that is wrong is returned in one error.
+func Load(path string) (*AppConfig, error) {
+	c := &AppConfig{
+		Port:         defaultPort,
+		TemplateDirs: []string{defaultTemplateDir},
+		StaticDir:    defaultStaticDir,
+	}
+
+	optional := path == ""
+	if optional {
+		path = DefaultConfigFile
+	}
+	err := c.loadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && optional {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadFile copies the fields set in the config file into c
+func (c *AppConfig) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	defer f.Close()
+
+	var fc fileConfig
+	dec := json.NewDecoder(f)
+	// a typo in a key would otherwise be ignored without a word
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&fc)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	if fc.Port != "" {
+		c.Port = fc.Port
+	}
+	if fc.InProduction != nil {
+		c.InProduction = *fc.InProduction
+	}
+	if fc.TemplateDir != "" {
+		c.TemplateDirs = []string{fc.TemplateDir}
+	}
+	if fc.StaticDir != "" {
+		c.StaticDir = fc.StaticDir
+	}
+	if fc.BaseURL != "" {
+		c.BaseURL = fc.BaseURL
+	}
+	if fc.SessionSecret != "" {
+		c.SessionSecret = fc.SessionSecret
+	}
+	if len(fc.AllowedHosts) > 0 {
+		c.AllowedHosts = fc.AllowedHosts
+	}
+	if fc.DSN != "" {
+		c.DSN = fc.DSN
+	}
+	if fc.AuditLogPath != "" {
+		c.AuditLogPath = fc.AuditLogPath
+	}
+	if fc.AdminUser != "" {
+		c.AdminUser = fc.AdminUser
+	}
+	if fc.AdminPassword != "" {
+		c.AdminPasswordHash = fc.AdminPassword
+	}
+	if len(fc.TrustedProxies) > 0 {
+		c.TrustedProxies = fc.TrustedProxies
+	}
+	if len(fc.APIKeys) > 0 {
+		c.APIKeys = fc.APIKeys
+	}
+	return nil
+}
+
+// loadEnv copies the environment variables that are set into c
+func (c *AppConfig) loadEnv() error {
+	if v := os.Getenv("PORT"); v != "" {
+		c.Port = v
+	}
+	if v := os.Getenv("IN_PRODUCTION"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("config: IN_PRODUCTION must be true or false, got %q", v)
+		}
+		c.InProduction = b
+	}
+	if v := os.Getenv("TEMPLATE_DIR"); v != "" {
+		c.TemplateDirs = []string{v}
+	}
+	if v := os.Getenv("STATIC_DIR"); v != "" {
+		c.StaticDir = v
+	}
+	if v := os.Getenv("BASE_URL"); v != "" {
+		c.BaseURL = v
+	}
+	if v := os.Getenv("SESSION_SECRET"); v != "" {
+		c.SessionSecret = v
+	}
+	if v := os.Getenv("ALLOWED_HOSTS"); v != "" {
+		c.AllowedHosts = splitList(v)
+	}
+	if v := os.Getenv("DATABASE_URL"); v != "" {
+		c.DSN = v
+	}
+	if v := os.Getenv("AUDIT_LOG"); v != "" {
+		c.AuditLogPath = v
+	}
+	if v := os.Getenv("ADMIN_USER"); v != "" {
+		c.AdminUser = v
+	}
+	if v := os.Getenv("ADMIN_PASSWORD_HASH"); v != "" {
+		c.AdminPasswordHash = v
+	}
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		c.TrustedProxies = splitList(v)
+	}
+	if v := os.Getenv("API_KEYS"); v != "" {
+		c.APIKeys = map[string]string{}
+		for _, entry := range splitList(v) {
+			clientID, hash, ok := strings.Cut(entry, ":")
+			if !ok {
+				return fmt.Errorf("config: API_KEYS entries must be client:sha256hex, got %q", entry)
+			}
+			c.APIKeys[strings.TrimSpace(clientID)] = strings.TrimSpace(hash)
+		}
+	}
+	return nil
+}
+
+// validate checks the loaded values, RequireForProduction adds the checks that only matter in production
+func (c *AppConfig) validate() error {
+	var errs []error
+	n, err := strconv.Atoi(c.Port)
+	if err != nil || n < 1 || n > 65535 {
+		errs = append(errs, fmt.Errorf("Port must be a number from 1 to 65535, got %q", c.Port))
+	}
+	if len(c.TemplateDirs) == 0 || c.TemplateDirs[0] == "" {
+		errs = append(errs, errors.New("TemplateDir must be set"))
+	}
+	if c.StaticDir == "" {
+		errs = append(errs, errors.New("StaticDir must be set"))
+	}
+	for _, entry := range c.TrustedProxies {
+		entry = strings.TrimSpace(entry)
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			errs = append(errs, fmt.Errorf("TrustedProxies must be IPs or CIDRs, got %q", entry))
+		}
+	}
+	for clientID, hash := range c.APIKeys {
+		if clientID == "" {
+			errs = append(errs, errors.New("APIKeys has an entry without a client id"))
+		}
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+			errs = append(errs, fmt.Errorf("APIKeys[%q] must be a hex sha256 hash", clientID))
+		}
+	}
+	if err := c.RequireForProduction(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// splitList splits a comma separated env value, the spaces around each entry and the empty entries are dropped
+func splitList(v string) []string {
+	var list []string
+	for _, entry := range strings.Split(v, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// envVars are all the variables Load reads, cleared so the environment running the tests doesn't leak in
+var envVars = []string{"PORT", "IN_PRODUCTION", "TEMPLATE_DIR", "STATIC_DIR", "BASE_URL", "SESSION_SECRET",
+	"ALLOWED_HOSTS", "DATABASE_URL", "AUDIT_LOG", "ADMIN_USER", "ADMIN_PASSWORD_HASH", "TRUSTED_PROXIES", "API_KEYS"}
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envVars {
+		t.Setenv(name, "")
+	}
+}
+
+// configFile writes content as a config file in a temp dir and returns its path
+func configFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := configFile(t, `{"port": "9090", "template_dir": "/srv/templates", "static_dir": "/srv/static"}`)
+	t.Setenv("PORT", "7070")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the env beats the file, the file beats the defaults
+	if c.Port != "7070" {
+		t.Errorf("Port = %q, want the env's 7070", c.Port)
+	}
+	if !reflect.DeepEqual(c.TemplateDirs, []string{"/srv/templates"}) || c.StaticDir != "/srv/static" {
+		t.Errorf("got %v %q, want the file's dirs", c.TemplateDirs, c.StaticDir)
+	}
+	if c.InProduction {
+		t.Error("InProduction is on by default")
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	// without a path the missing config.json is fine, every value is a default
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != defaultPort || c.StaticDir != defaultStaticDir || !reflect.DeepEqual(c.TemplateDirs, []string{defaultTemplateDir}) {
+		t.Errorf("got %q %q %v, want the defaults", c.Port, c.StaticDir, c.TemplateDirs)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IN_PRODUCTION", "true")
+	t.Setenv("SESSION_SECRET", strings.Repeat("s", minSessionSecretLength))
+	t.Setenv("BASE_URL", "https://example.com")
+	t.Setenv("ALLOWED_HOSTS", " example.com, www.example.com,")
+
+	c, err := Load(configFile(t, `{"in_production": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.InProduction || !reflect.DeepEqual(c.AllowedHosts, []string{"example.com", "www.example.com"}) {
+		t.Errorf("got production %v hosts %v", c.InProduction, c.AllowedHosts)
+	}
+}
+
+func TestLoadProxiesAndAPIKeys(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+
+	clearEnv(t)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+	t.Setenv("API_KEYS", "mobile:"+hash+", partner : "+hash)
+	c, err := Load(configFile(t, `{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.TrustedProxies, []string{"10.0.0.0/8", "127.0.0.1"}) {
+		t.Errorf("TrustedProxies = %q", c.TrustedProxies)
+	}
+	if want := map[string]string{"mobile": hash, "partner": hash}; !reflect.DeepEqual(c.APIKeys, want) {
+		t.Errorf("APIKeys = %v, want %v", c.APIKeys, want)
+	}
+
+	// the same values can come from the file
+	clearEnv(t)
+	c, err = Load(configFile(t, `{"trusted_proxies": ["192.168.1.5"], "api_keys": {"mobile": "`+hash+`"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.TrustedProxies, []string{"192.168.1.5"}) || c.APIKeys["mobile"] != hash {
+		t.Errorf("got proxies %q keys %v from the file", c.TrustedProxies, c.APIKeys)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		env    map[string]string
+		errors []string
+	}{
+		{"bad port", `{"port": "eighty"}`, nil, []string{`Port must be a number from 1 to 65535, got "eighty"`}},
+		{"unknown key", `{"prot": "9090"}`, nil, []string{`unknown field "prot"`}},
+		{"not json", `port=9090`, nil, []string{"reading"}},
+		{"bad bool", `{}`, map[string]string{"IN_PRODUCTION": "yes please"}, []string{"IN_PRODUCTION must be true or false"}},
+		{"bad proxy", `{"trusted_proxies": ["10.0.0.0/99"]}`, nil, []string{`TrustedProxies must be IPs or CIDRs, got "10.0.0.0/99"`}},
+		{"bad proxy env", `{}`, map[string]string{"TRUSTED_PROXIES": "10.0.0.1,proxy.local"}, []string{`"proxy.local"`}},
+		{"api key without hash", `{}`, map[string]string{"API_KEYS": "mobile"}, []string{`API_KEYS entries must be client:sha256hex, got "mobile"`}},
+		{"api key not sha256", `{}`, map[string]string{"API_KEYS": "mobile:secret"}, []string{`APIKeys["mobile"] must be a hex sha256 hash`}},
+		{"api key without client", `{"api_keys": {"": "` + strings.Repeat("ab", 32) + `"}}`, nil, []string{"APIKeys has an entry without a client id"}},
+		// every problem at once, the production checks included
+		{"production without its settings", `{"port": "0", "in_production": true}`, nil, []string{"Port", "SessionSecret", "BaseURL", "AllowedHosts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(configFile(t, tt.file))
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			for _, want := range tt.errors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	// a path that was given has to exist
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("a missing config file that was asked for is not an error")
+	}
+}
@@ -0,0 +1,39 @@
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// FieldName is the form field the token is posted in, the csrfField template func writes it
+	FieldName = "csrf_token"
+	// HeaderName is where scripts (and multipart uploads) send the token instead of the form field
+	HeaderName = "X-CSRF-Token"
+	// CookieName is the cookie holding the token of the browser, a posted token must match it
+	CookieName = "csrf_token"
+)
+
+// contextKey is unexported so no other package can overwrite our value in the context
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the CSRF token of the request
+func NewContext(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// Token returns the CSRF token stored in ctx, or "" when the CSRF middleware didn't run
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(contextKey{}).(string)
+	return token
+}
+
+// Sign returns the token the forms must post for the cookie value: an HMAC of it with the key (the app's SessionSecret).
+// Without the key the token can't be made from the cookie, so a cookie planted by eg: a subdomain is no good on its own.
+func Sign(key []byte, cookieValue string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(cookieValue))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,21 @@
+package csrf
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	key := []byte("a key")
+	token := Sign(key, "cookie")
+
+	if len(token) != 64 {
+		t.Errorf("token %q is not a hex sha256", token)
+	}
+	if token == "cookie" || token != Sign(key, "cookie") {
+		t.Errorf("the token must be made from the cookie, the same way every time")
+	}
+	if token == Sign([]byte("another key"), "cookie") {
+		t.Error("another key made the same token")
+	}
+	if token == Sign(key, "another cookie") {
+		t.Error("another cookie made the same token")
+	}
+}
@@ -0,0 +1,75 @@
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Flags decides if a feature is turned on for the request behind ctx
+type Flags interface {
+	Enabled(ctx context.Context, name string) bool
+}
+
+// Static is a Flags backed by a fixed map of flag name => percentage of users that get the feature (0 off, 100 everyone)
+type Static struct {
+	rollout map[string]int
+}
+
+// NewStatic creates the flags from the rollout map, unknown flags are always off
+func NewStatic(rollout map[string]int) *Static {
+	return &Static{rollout: rollout}
+}
+
+// Enabled reports if the flag is on for the subject (user or session) in ctx. For a partial rollout the subject is hashed
+// together with the flag name, so the same user always gets the same answer and each flag picks a different set of users.
+func (s *Static) Enabled(ctx context.Context, name string) bool {
+	percent := s.rollout[name]
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+
+	subject, ok := SubjectFromContext(ctx)
+	if !ok {
+		// without a subject we can't bucket consistently, so only fully rolled out flags are on
+		return false
+	}
+	return bucket(name, subject) < percent
+}
+
+// bucket maps the flag and subject to a number in [0, 100)
+func bucket(name, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+type flagsKey struct{}
+type subjectKey struct{}
+
+// NewContext returns a copy of ctx carrying the evaluator
+func NewContext(ctx context.Context, f Flags) context.Context {
+	return context.WithValue(ctx, flagsKey{}, f)
+}
+
+// WithSubject returns a copy of ctx carrying the id (user or session) flags are bucketed by
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the bucketing id stored in ctx
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey{}).(string)
+	return subject, ok && subject != ""
+}
+
+// Enabled asks the evaluator stored in ctx about the flag. Without an evaluator every flag is off.
+func Enabled(ctx context.Context, name string) bool {
+	f, ok := ctx.Value(flagsKey{}).(Flags)
+	if !ok {
+		return false
+	}
+	return f.Enabled(ctx, name)
+}
@@ -0,0 +1,71 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStaticEnabled(t *testing.T) {
+	f := NewStatic(map[string]int{"on": 100, "off": 0, "half": 50})
+	ctx := WithSubject(context.Background(), "user-1")
+
+	if !f.Enabled(ctx, "on") {
+		t.Error("a 100% flag is off")
+	}
+	if f.Enabled(ctx, "off") {
+		t.Error("a 0% flag is on")
+	}
+	if f.Enabled(ctx, "unknown") {
+		t.Error("an unknown flag is on")
+	}
+	// a partial rollout needs someone to bucket
+	if f.Enabled(context.Background(), "half") {
+		t.Error("a 50% flag is on without a subject")
+	}
+	if !f.Enabled(context.Background(), "on") {
+		t.Error("a 100% flag is off without a subject")
+	}
+}
+
+func TestStaticBucketing(t *testing.T) {
+	f := NewStatic(map[string]int{"half": 50, "other": 50})
+
+	enabled, differ := 0, 0
+	for i := 0; i < 1000; i++ {
+		ctx := WithSubject(context.Background(), fmt.Sprintf("user-%d", i))
+		on := f.Enabled(ctx, "half")
+
+		// the same user always gets the same answer
+		for j := 0; j < 3; j++ {
+			if f.Enabled(ctx, "half") != on {
+				t.Fatalf("user-%d flips between requests", i)
+			}
+		}
+		if on {
+			enabled++
+		}
+		if f.Enabled(ctx, "other") != on {
+			differ++
+		}
+	}
+
+	if enabled < 400 || enabled > 600 {
+		t.Errorf("%d of 1000 users got a 50%% flag", enabled)
+	}
+	// each flag picks its own users instead of the same half every time
+	if differ < 300 {
+		t.Errorf("only %d of 1000 users differ between two 50%% flags", differ)
+	}
+}
+
+func TestEnabledFromContext(t *testing.T) {
+	ctx := WithSubject(context.Background(), "user-1")
+	if Enabled(ctx, "on") {
+		t.Error("a flag is on without an evaluator in the context")
+	}
+	ctx = NewContext(ctx, NewStatic(map[string]int{"on": 100}))
+	if !Enabled(ctx, "on") || Enabled(ctx, "off") {
+		t.Error("the evaluator in the context was not used")
+	}
+}
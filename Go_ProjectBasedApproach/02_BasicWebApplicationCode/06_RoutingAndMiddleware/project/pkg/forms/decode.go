@@ -0,0 +1,107 @@
+package forms
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is returned by DecodeForm when some posted values couldn't be converted, Errors has the message per field
+// so the form can be shown again with the message next to each field
+type DecodeError struct {
+	Errors Errors
+}
+
+func (e *DecodeError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return "forms: invalid value for " + strings.Join(fields, ", ")
+}
+
+// DecodeForm parses the posted form and fills the fields of the struct dst points to, the field's
+// `form:"email"` tag names the form field. Strings, ints and bools are supported: a missing or empty value
+// leaves the zero value, a checkbox's "on" is true. Every value that can't be converted is collected
+// into a *DecodeError instead of stopping at the first one, eg:
+//
+//	var contact struct {
+//		Email string `form:"email"`
+//		Age   int    `form:"age"`
+//		News  bool   `form:"newsletter"`
+//	}
+//	err := forms.DecodeForm(r, &contact)
+func DecodeForm(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeForm: dst must be a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	errs := Errors{}
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := t.Field(i).Tag.Lookup("form")
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+
+		raw := strings.TrimSpace(r.PostForm.Get(name))
+		message, err := decodeField(v.Field(i), raw)
+		if err != nil {
+			return fmt.Errorf("DecodeForm: field %s %v", t.Field(i).Name, err)
+		}
+		if message != "" {
+			errs.Add(name, message)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &DecodeError{Errors: errs}
+	}
+	return nil
+}
+
+// decodeField converts raw to the type of the field and stores it. message is for the user (a bad value),
+// err is for the developer (a field type DecodeForm doesn't support).
+func decodeField(f reflect.Value, raw string) (message string, err error) {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Bool:
+		switch raw {
+		case "":
+			f.SetBool(false)
+		case "on":
+			f.SetBool(true)
+		default:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return "This field must be true or false", nil
+			}
+			f.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if raw == "" {
+			f.SetInt(0)
+			return "", nil
+		}
+		n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return "This field must be a whole number", nil
+		}
+		f.SetInt(n)
+	default:
+		return "", fmt.Errorf("has the unsupported type %s", f.Type())
+	}
+	return "", nil
+}
@@ -0,0 +1,118 @@
+package forms
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// signup has one field of every type DecodeForm supports
+type signup struct {
+	Email  string `form:"email"`
+	Age    int    `form:"age"`
+	News   bool   `form:"newsletter"`
+	Secret string
+}
+
+// postForm builds a POST request with the values as its body
+func postForm(values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestDecodeForm(t *testing.T) {
+	var dst signup
+	err := DecodeForm(postForm(url.Values{
+		"email":      {"  me@here.com "},
+		"age":        {"42"},
+		"newsletter": {"on"},
+		"Secret":     {"not tagged"},
+	}), &dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := signup{Email: "me@here.com", Age: 42, News: true}
+	if dst != want {
+		t.Errorf("got %+v, want %+v", dst, want)
+	}
+}
+
+func TestDecodeFormEmptyValues(t *testing.T) {
+	dst := signup{Email: "old", Age: 7, News: true}
+	err := DecodeForm(postForm(url.Values{"age": {""}}), &dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != (signup{}) {
+		t.Errorf("missing and empty values should leave the zero value, got %+v", dst)
+	}
+}
+
+func TestDecodeFormBadValues(t *testing.T) {
+	var dst signup
+	err := DecodeForm(postForm(url.Values{
+		"email":      {"me@here.com"},
+		"age":        {"forty two"},
+		"newsletter": {"maybe"},
+	}), &dst)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want a *DecodeError, got %v", err)
+	}
+	// every bad field is reported, not just the first one
+	if got := decodeErr.Errors.Get("age"); got != "This field must be a whole number" {
+		t.Errorf("age error = %q", got)
+	}
+	if got := decodeErr.Errors.Get("newsletter"); got != "This field must be true or false" {
+		t.Errorf("newsletter error = %q", got)
+	}
+	if got := err.Error(); got != "forms: invalid value for age, newsletter" {
+		t.Errorf("Error() = %q", got)
+	}
+	// the good fields are still filled
+	if dst.Email != "me@here.com" {
+		t.Errorf("Email = %q", dst.Email)
+	}
+}
+
+func TestDecodeFormIntOverflow(t *testing.T) {
+	var dst struct {
+		Small int8 `form:"small"`
+	}
+	err := DecodeForm(postForm(url.Values{"small": {"300"}}), &dst)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Errors.Get("small") == "" {
+		t.Errorf("300 doesn't fit an int8, got %v", err)
+	}
+}
+
+func TestDecodeFormBadDestination(t *testing.T) {
+	var unsupported struct {
+		Price float64 `form:"price"`
+	}
+	tests := []struct {
+		name string
+		dst  interface{}
+	}{
+		{"not a pointer", signup{}},
+		{"not a struct", new(string)},
+		{"unsupported field type", &unsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeForm(postForm(url.Values{"price": {"1.5"}}), tt.dst)
+			var decodeErr *DecodeError
+			if err == nil || errors.As(err, &decodeErr) {
+				t.Errorf("want a developer error, got %v", err)
+			}
+		})
+	}
+}
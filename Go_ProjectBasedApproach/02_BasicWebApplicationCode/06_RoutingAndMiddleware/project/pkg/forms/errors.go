@@ -0,0 +1,18 @@
+package forms
+
+// Errors holds the validation messages of a form, field name => messages
+type Errors map[string][]string
+
+// Add adds an error message for the given field
+func (e Errors) Add(field, message string) {
+	e[field] = append(e[field], message)
+}
+
+// Get returns the first error message of the field, or "" when the field has none
+func (e Errors) Get(field string) string {
+	es := e[field]
+	if len(es) == 0 {
+		return ""
+	}
+	return es[0]
+}
@@ -0,0 +1,84 @@
+package forms
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// emailPattern is a basic email check (something@something.tld), it is compiled once here instead of on every call
+var emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+
+// Form wraps the posted form values and gives us helpers that work on them
+type Form struct {
+	url.Values
+	Errors Errors
+}
+
+// New creates a Form from the parsed values (eg: r.PostForm)
+func New(data url.Values) *Form {
+	return &Form{
+		Values: data,
+		Errors: Errors{},
+	}
+}
+
+// Valid returns true when no field has an error
+func (f *Form) Valid() bool {
+	return len(f.Errors) == 0
+}
+
+// Required adds an error for every field that is empty
+func (f *Form) Required(fields ...string) {
+	for _, field := range fields {
+		if strings.TrimSpace(f.Get(field)) == "" {
+			f.Errors.Add(field, "This field cannot be blank")
+		}
+	}
+}
+
+// MinLength adds an error when the field has fewer than n characters
+func (f *Form) MinLength(field string, n int) bool {
+	if utf8.RuneCountInString(f.Get(field)) < n {
+		f.Errors.Add(field, fmt.Sprintf("This field must be at least %d characters long", n))
+		return false
+	}
+	return true
+}
+
+// IsEmail adds an error when the field is not a valid email address
+func (f *Form) IsEmail(field string) bool {
+	if !emailPattern.MatchString(f.Get(field)) {
+		f.Errors.Add(field, "Invalid email address")
+		return false
+	}
+	return true
+}
+
+// Sanitize cleans every value of the form: leading/trailing whitespace is trimmed and control characters are removed.
+// Call it after parsing and before validating. Fields listed in raw (eg: "password") are left exactly as the user typed them.
+func (f *Form) Sanitize(raw ...string) {
+	for field, values := range f.Values {
+		if slices.Contains(raw, field) {
+			continue
+		}
+		for i, v := range values {
+			values[i] = sanitize(v)
+		}
+	}
+}
+
+// sanitize trims the value and drops control characters, new lines and tabs are kept since textareas need them
+func sanitize(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, v)
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,115 @@
+package forms
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	form := New(url.Values{
+		"name":     {"  Rahul \t"},
+		"password": {"  two words  "},
+		"message":  {"line one\nline\x00 two\x07"},
+	})
+	form.Sanitize("password")
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"name", "Rahul"},
+		{"password", "  two words  "},
+		{"message", "line one\nline two"},
+	}
+	for _, tt := range tests {
+		if got := form.Get(tt.field); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeBeforeValidation(t *testing.T) {
+	// after trimming, a name of only spaces is blank and a padded email is valid
+	form := New(url.Values{
+		"name":  {"   "},
+		"email": {" me@here.com "},
+	})
+	form.Sanitize()
+	form.Required("name")
+	form.IsEmail("email")
+
+	if form.Errors.Get("name") == "" {
+		t.Error("a name of only spaces is not blank")
+	}
+	if msg := form.Errors.Get("email"); msg != "" {
+		t.Errorf("the trimmed email failed validation: %s", msg)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	form := New(url.Values{"name": {"Rahul"}, "email": {"  "}})
+	form.Required("name", "email", "phone")
+
+	if form.Valid() {
+		t.Fatal("the form is valid with blank fields")
+	}
+	if msg := form.Errors.Get("name"); msg != "" {
+		t.Errorf("name: %s", msg)
+	}
+	for _, field := range []string{"email", "phone"} {
+		if got := form.Errors.Get(field); got != "This field cannot be blank" {
+			t.Errorf("%s: error = %q", field, got)
+		}
+	}
+}
+
+func TestMinLength(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"", false},
+		{"R", false},
+		{"Ra", true},
+		// characters are counted, not bytes
+		{"é", false},
+		{"éé", true},
+	}
+
+	for _, tt := range tests {
+		form := New(url.Values{"name": {tt.value}})
+		if got := form.MinLength("name", 2); got != tt.valid || form.Valid() != tt.valid {
+			t.Errorf("MinLength(%q) = %v, want %v", tt.value, got, tt.valid)
+		}
+		if !tt.valid && form.Errors.Get("name") != "This field must be at least 2 characters long" {
+			t.Errorf("%q: error = %q", tt.value, form.Errors.Get("name"))
+		}
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	valid := []string{"me@here.com", "first.last@sub.example.org", "a+tag@x.io"}
+	invalid := []string{"", "me", "me@here", "@here.com", "me@.com", "me@here.", "two words@here.com", "me@@here.com"}
+
+	for _, email := range valid {
+		form := New(url.Values{"email": {email}})
+		if !form.IsEmail("email") || !form.Valid() {
+			t.Errorf("%q was rejected", email)
+		}
+	}
+	for _, email := range invalid {
+		form := New(url.Values{"email": {email}})
+		if form.IsEmail("email") || form.Errors.Get("email") != "Invalid email address" {
+			t.Errorf("%q was accepted", email)
+		}
+	}
+}
+
+// BenchmarkIsEmail shows the pattern is compiled once: a valid address costs no allocations per call
+func BenchmarkIsEmail(b *testing.B) {
+	form := New(url.Values{"email": {"first.last@sub.example.org"}})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		form.IsEmail("email")
+	}
+}
@@ -0,0 +1,119 @@
+package forms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// AllowedUploadTypes are the content types SaveUploadedFile accepts, checked by sniffing the file itself
+// (the Content-Type the browser sends can't be trusted)
+var AllowedUploadTypes = []string{"image/png", "image/jpeg", "image/gif", "application/pdf", "text/plain"}
+
+var (
+	// ErrNoFile is returned when the form has no file in the requested field
+	ErrNoFile = errors.New("forms: no file uploaded")
+	// ErrFileTooLarge is returned when the body is bigger than the allowed size
+	ErrFileTooLarge = errors.New("forms: uploaded file is too large")
+	// ErrFileType is returned when the sniffed content type is not in AllowedUploadTypes
+	ErrFileType = errors.New("forms: file type not allowed")
+)
+
+// SaveUploadedFile streams the file of the multipart form field to destDir and returns the path it was saved to.
+// Nothing is buffered in memory: the body is capped at maxBytes with http.MaxBytesReader and copied to a temp file,
+// which is only renamed into place once everything went well. On any error the temp file is removed.
+func SaveUploadedFile(w http.ResponseWriter, r *http.Request, field, destDir string, maxBytes int64) (path string, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return "", err
+	}
+
+	// walk the parts until we find the file we are looking for
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return "", ErrNoFile
+		}
+		if err != nil {
+			return "", uploadError(err)
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		defer part.Close()
+
+		// sniff the type from the first 512 bytes, that is all http.DetectContentType looks at
+		head := make([]byte, 512)
+		n, err := io.ReadFull(part, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return "", uploadError(err)
+		}
+		head = head[:n]
+
+		mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if !slices.Contains(AllowedUploadTypes, mediaType) {
+			return "", fmt.Errorf("%w: %s", ErrFileType, mediaType)
+		}
+
+		tmp, err := os.CreateTemp(destDir, ".upload-*")
+		if err != nil {
+			return "", err
+		}
+		// until the rename succeeds the temp file is deleted on the way out
+		defer func() {
+			if err != nil {
+				tmp.Close()
+				os.Remove(tmp.Name())
+			}
+		}()
+
+		if _, err = tmp.Write(head); err != nil {
+			return "", err
+		}
+		if _, err = io.Copy(tmp, part); err != nil {
+			return "", uploadError(err)
+		}
+		if err = tmp.Close(); err != nil {
+			return "", err
+		}
+
+		// the user's file name is only used for its extension, the name itself is random so uploads never overwrite each other
+		name, err := randomName()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(destDir, name+filepath.Ext(filepath.Base(part.FileName())))
+		if err = os.Rename(tmp.Name(), path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+}
+
+// uploadError turns the error of a body over the size limit into ErrFileTooLarge
+func uploadError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return ErrFileTooLarge
+	}
+	return err
+}
+
+// randomName returns 16 random bytes as hex
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
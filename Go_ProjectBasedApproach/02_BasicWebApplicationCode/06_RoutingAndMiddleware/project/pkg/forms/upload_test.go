@@ -0,0 +1,99 @@
+package forms
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadRequest builds a multipart POST with the file in field, next to a normal text field
+func uploadRequest(t *testing.T, field, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "my file")
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// dirEntries returns the names of everything in dir, hidden temp files included
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestSaveUploadedFile(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(strings.Repeat("plain text notes\n", 100))
+
+	path, err := SaveUploadedFile(httptest.NewRecorder(), uploadRequest(t, "doc", "../../notes.txt", content), "doc", dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file lands in dir under a random name, the user's path is not used
+	if filepath.Dir(path) != dir || filepath.Ext(path) != ".txt" || strings.Contains(path, "notes") {
+		t.Errorf("saved to %q", path)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("saved %d bytes, want the %d uploaded", len(got), len(content))
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("dir holds %v, want only the saved file", names)
+	}
+}
+
+func TestSaveUploadedFileRejected(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+	tests := []struct {
+		name     string
+		field    string
+		content  []byte
+		maxBytes int64
+		want     error
+	}{
+		{"too large", "doc", bytes.Repeat([]byte("a"), 64<<10), 16 << 10, ErrFileTooLarge},
+		{"type", "doc", []byte("<html><script>alert(1)</script></html>"), 1 << 20, ErrFileType},
+		{"no file", "other", png, 1 << 20, ErrNoFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := SaveUploadedFile(httptest.NewRecorder(), uploadRequest(t, tt.field, "f.bin", tt.content), "doc", dir, tt.maxBytes)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			// no partial upload or temp file is left behind
+			if names := dirEntries(t, dir); len(names) != 0 {
+				t.Errorf("dir holds %v after the error", names)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rahulrai17/porject/pkg/render"
+)
+
+// statusClientClosedRequest is the (nginx) status used in the logs when the client went away before we answered
+const statusClientClosedRequest = 499
+
+// HandlerFunc is a handler that returns its error instead of writing the error response itself,
+// ServeHTTP then picks the right status for the error in one place.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP runs the handler and turns the returned error into a response:
+// a context deadline (eg: from the timeout middleware or a slow outbound call) is a 504 Gateway Timeout,
+// a cancelled context means the client is gone so nothing useful can be sent, a bad path param is a 400 and anything else is a 500.
+func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := h(w, r)
+	if err == nil {
+		return
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		infoLog().Info("client closed the request", "method", r.Method, "path", r.URL.Path, "error", err)
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, ErrBadParam):
+		infoLog().Info("bad path param", "method", r.Method, "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusBadRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		errorLog().Error("timed out", "method", r.Method, "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusGatewayTimeout)
+	default:
+		errorLog().Error("handler failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusInternalServerError)
+	}
+}
+
+// writeError sends the status through render.RenderError: the JSON error envelope for api requests,
+// a small fragment for htmx requests or the error page
+func writeError(w http.ResponseWriter, r *http.Request, status int) {
+	render.RenderError(w, r, status, http.StatusText(status))
+}
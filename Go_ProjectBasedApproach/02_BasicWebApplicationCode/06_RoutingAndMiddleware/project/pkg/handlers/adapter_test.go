@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerFuncStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"deadline", fmt.Errorf("calling upstream: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"canceled", fmt.Errorf("calling upstream: %w", context.Canceled), statusClientClosedRequest},
+		{"bad param", fmt.Errorf("%w: id", ErrBadParam), http.StatusBadRequest},
+		{"other", errors.New("db is down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return tt.err })
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reservations", nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			// the client is gone, there is nobody to read a body
+			if tt.status == statusClientClosedRequest && rr.Body.Len() != 0 {
+				t.Errorf("a body was written for a closed request: %q", rr.Body)
+			}
+			if tt.status != statusClientClosedRequest && !strings.Contains(rr.Body.String(), fmt.Sprintf(`"status":%d`, tt.status)) {
+				t.Errorf("body = %q, want the error envelope", rr.Body)
+			}
+		})
+	}
+}
+
+func TestHandlerFuncNoError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	})
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusAccepted || rr.Body.Len() != 0 {
+		t.Errorf("got %d %q, want the handler's own response", rr.Code, rr.Body)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/pkg/repository"
+)
+
+// postJSON sends the body to the router like an api client would
+func postJSON(t *testing.T, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPostReservationJSONBadBody(t *testing.T) {
+	withStore(t, repository.NewMemoryStore())
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"badly formed", `{"first_name":`, http.StatusBadRequest},
+		{"unknown field", `{"first_name":"Rahul","admin":true}`, http.StatusBadRequest},
+		{"too large", `{"first_name":"` + strings.Repeat("a", 1<<20) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postJSON(t, "/api/reservations", tt.body)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.status, rr.Body)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+		})
+	}
+}
+
+func TestPostReservationJSON(t *testing.T) {
+	withStore(t, storeWith(t, 2))
+
+	// the id and created_at are the store's, whatever the client sends for them is thrown away
+	rr := postJSON(t, "/api/reservations", `{"id":99,"created_at":"2001-01-01T00:00:00Z","first_name":"Rahul","last_name":"Rai","email":"rahul@example.com","phone":"555"}`)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rr.Code, rr.Body)
+	}
+
+	var body struct {
+		Data    models.Reservation `json:"data"`
+		Message string             `json:"message"`
+	}
+	err := json.Unmarshal(rr.Body.Bytes(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Message != "Reservation created" {
+		t.Errorf("message = %q", body.Message)
+	}
+	got := body.Data
+	if got.ID != 3 || got.FirstName != "Rahul" || got.LastName != "Rai" || got.Email != "rahul@example.com" || got.Phone != "555" {
+		t.Errorf("data = %+v", got)
+	}
+	if time.Since(got.CreatedAt) > time.Minute {
+		t.Errorf("created_at = %v, want the time it was stored", got.CreatedAt)
+	}
+
+	// what was sent back is what is listed
+	stored, err := Repo.DB.AllReservations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := stored[len(stored)-1]; !last.CreatedAt.Equal(got.CreatedAt) || last.ID != got.ID {
+		t.Errorf("stored %+v, sent back %+v", last, got)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCalc(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		body   string
+	}{
+		{"empty form", "", http.StatusOK, "<h1>Calculator</h1>"},
+		// html/template writes the + as &#43;
+		{"add", "?a=6&b=3&op=add", http.StatusOK, "<p>6 &#43; 3 = 9</p>"},
+		{"sub", "?a=6&b=3&op=sub", http.StatusOK, "<p>6 - 3 = 3</p>"},
+		{"mul", "?a=6&b=3&op=mul", http.StatusOK, "<p>6 × 3 = 18</p>"},
+		{"div", "?a=7&b=2&op=div", http.StatusOK, "<p>7 ÷ 2 = 3.5</p>"},
+		{"divide by zero", "?a=1&b=0&op=div", http.StatusBadRequest, "cannot divide by zero"},
+		{"not a number", "?a=six&b=3&op=add", http.StatusBadRequest, "&#34;six&#34; is not a number"},
+		{"NaN", "?a=NaN&b=3&op=add", http.StatusBadRequest, "&#34;NaN&#34; is not a number"},
+		{"unknown operation", "?a=1&b=2&op=pow", http.StatusBadRequest, "unknown operation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/calc"+tt.query, nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q", tt.body)
+			}
+			// a failed calculation shows no result
+			if tt.status != http.StatusOK && strings.Contains(rr.Body.String(), " = ") {
+				t.Errorf("the error page shows a result: %q", rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFlashAfterRedirect(t *testing.T) {
+	ts := httptest.NewServer(getRoutes())
+	t.Cleanup(ts.Close)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ts.Client()
+	client.Jar = jar
+
+	body := func(resp *http.Response, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d", resp.Request.URL, resp.StatusCode)
+		}
+		return string(b)
+	}
+	const flash = "Thanks, we will get back to you soon!"
+
+	// the client follows the 303 to the contact page, which shows the flash put in the session before it
+	page := body(client.PostForm(ts.URL+"/contact", url.Values{"name": {"Rahul"}, "email": {"rahul@example.com"}}))
+	if !strings.Contains(page, flash) {
+		t.Errorf("the page after the redirect has no flash: %q", page)
+	}
+
+	// showing it popped it from the session
+	page = body(client.Get(ts.URL + "/contact"))
+	if strings.Contains(page, flash) {
+		t.Error("the flash is shown a second time")
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/forms"
+	"github.com/rahulrai17/porject/pkg/repository"
+)
+
+// postForm sends the values to the router like a browser submitting a form
+func postForm(t *testing.T, path string, values url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPostContact(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   url.Values
+		status   int
+		location string
+		body     string
+	}{
+		{"valid", url.Values{"name": {"Rahul"}, "email": {"rahul@example.com"}, "message": {"Hi"}}, http.StatusSeeOther, "/contact", ""},
+		{"name too short", url.Values{"name": {" R "}, "email": {"rahul@example.com"}}, http.StatusUnprocessableEntity, "", "at least 2 characters"},
+		{"bad email", url.Values{"name": {"Rahul"}, "email": {"nope"}}, http.StatusUnprocessableEntity, "", "Invalid email address"},
+		{"empty", url.Values{}, http.StatusUnprocessableEntity, "", "This field cannot be blank"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postForm(t, "/contact", tt.values)
+
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.status, rr.Body)
+			}
+			if got := rr.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("body does not contain %q", tt.body)
+			}
+		})
+	}
+}
+
+func TestPostReservation(t *testing.T) {
+	store := repository.NewMemoryStore()
+	withStore(t, store)
+
+	rr := postForm(t, "/reservations", url.Values{
+		"first_name": {"  Rahul "},
+		"last_name":  {"Rai\x00"},
+		"email":      {"rahul@example.com"},
+		"phone":      {"555 1234"},
+		// the store picks the id, a posted one is ignored
+		"id": {"42"},
+	})
+
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/reservations" {
+		t.Fatalf("got %d to %q, want 303 to /reservations", rr.Code, rr.Header().Get("Location"))
+	}
+
+	reservations, err := store.AllReservations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reservations) != 1 {
+		t.Fatalf("got %d reservations, want 1", len(reservations))
+	}
+	got := reservations[0]
+	if got.ID != 1 || got.FirstName != "Rahul" || got.LastName != "Rai" || got.Email != "rahul@example.com" || got.Phone != "555 1234" {
+		t.Errorf("stored %+v", got)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Repo.redirect(rr, httptest.NewRequest(http.MethodPost, "/reservations", nil), "/reservations")
+
+	// 303 makes the browser follow with a GET, so a reload doesn't post the form again
+	if rr.Code != http.StatusSeeOther {
+		t.Errorf("status = %d, want 303", rr.Code)
+	}
+	if got := rr.Header().Get("Location"); got != "/reservations" {
+		t.Errorf("Location = %q, want /reservations", got)
+	}
+}
+
+func TestPostReservationWrongContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(`{"first_name":"Rahul"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", rr.Code)
+	}
+}
+
+func TestDecodeFormAddsErrors(t *testing.T) {
+	values := url.Values{"name": {"Rahul"}, "guests": {"lots"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := req.ParseForm(); err != nil {
+		t.Fatal(err)
+	}
+	form := forms.New(req.PostForm)
+
+	var dst struct {
+		Name   string `form:"name"`
+		Guests int    `form:"guests"`
+	}
+	err := decodeForm(req, form, &dst)
+	if err != nil {
+		t.Fatalf("a bad value belongs to the form, got the error %v", err)
+	}
+	if form.Valid() || form.Errors.Get("guests") != "This field must be a whole number" {
+		t.Errorf("form errors = %v", form.Errors)
+	}
+	if dst.Name != "Rahul" {
+		t.Errorf("Name = %q", dst.Name)
+	}
+}
+
+func TestRequireFormContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		ok          bool
+	}{
+		{"application/x-www-form-urlencoded", true},
+		{"application/x-www-form-urlencoded; charset=UTF-8", true},
+		{"multipart/form-data; boundary=xyz", true},
+		{"Multipart/Form-Data; boundary=xyz", true},
+		{"application/json", false},
+		{"text/plain", false},
+		{"", false},
+		{"not a media type;;", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader("name=Rahul"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			ok := requireFormContentType(rr, req)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok && rr.Code != http.StatusUnsupportedMediaType {
+				t.Errorf("status = %d, want 415", rr.Code)
+			}
+			if ok && rr.Body.Len() != 0 {
+				t.Errorf("an accepted post got a response: %q", rr.Body)
+			}
+		})
+	}
+}
+
+func TestPostContactJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(`{"name":"Rahul"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", rr.Code)
+	}
+}
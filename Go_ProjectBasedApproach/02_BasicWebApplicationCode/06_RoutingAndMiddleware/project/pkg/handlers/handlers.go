@@ -3,11 +3,32 @@ package handlers
 // Since both the file are in package main we can use the function or varible from one file to other
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/calc"
 	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/forms"
+	"github.com/rahulrai17/porject/pkg/httpclient"
+	"github.com/rahulrai17/porject/pkg/listquery"
+	"github.com/rahulrai17/porject/pkg/logging"
 	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/pkg/paginate"
 	"github.com/rahulrai17/porject/pkg/render"
+	"github.com/rahulrai17/porject/pkg/repository"
+	"github.com/rahulrai17/porject/pkg/worker"
 )
 
 // Repo the repository used by the handlers
@@ -16,12 +37,19 @@ var Repo *Repository
 // Repository is the repository type 
 type Repository struct{
 	App *config.AppConfig
+	DB repository.DataStore
+	Jobs *worker.Pool
+	HTTP *httpclient.Client
 }
 
 // NewRepo creates a new repository
-func NewRepo(a *config.AppConfig) *Repository{
+func NewRepo(a *config.AppConfig, db repository.DataStore, jobs *worker.Pool) *Repository{
 	return &Repository{
 		App: a,
+		DB: db,
+		Jobs: jobs,
+		// one client for every outbound call so connections are pooled
+		HTTP: httpclient.New(a.HTTPClientTimeout),
 	}
 }
 
@@ -30,11 +58,37 @@ func NewHandlers(r *Repository) {
 	Repo = r
 }
 
+// infoLog and errorLog are the loggers of the AppConfig, the default slog logger is used until NewHandlers was called
+func infoLog() *slog.Logger {
+	if Repo == nil || Repo.App == nil {
+		return slog.Default()
+	}
+	return logging.Or(Repo.App.InfoLog)
+}
+
+func errorLog() *slog.Logger {
+	if Repo == nil || Repo.App == nil {
+		return slog.Default()
+	}
+	return logging.Or(Repo.App.ErrorLog)
+}
+
+// redirect sends the browser to url with a 303 See Other after a POST (the Post/Redirect/Get pattern):
+// the browser follows it with a GET, so a reload shows the page again instead of posting the form twice.
+// Put a flash in the session first to show a message on the page we redirect to.
+func (m *Repository) redirect(w http.ResponseWriter, r *http.Request, url string) {
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+func init() { Register(http.MethodGet, "/home", page((*Repository).Home)) }
+
 // "H" in home is capital so that it can be accessed from other packages also
 func (m *Repository) Home(w http.ResponseWriter, r *http.Request){
-	render.RenderTemplate(w, "home.page.tmpl", &models.TemplateData{})
+	render.RenderTemplate(w, r, "home.page.tmpl", &models.TemplateData{})
 }
 
+func init() { Register(http.MethodGet, "/about", page((*Repository).About)) }
+
 // "w" send replies to the user of webpage , "r" keeps the request values from the user.
 func (m *Repository) About(w http.ResponseWriter, r *http.Request){
 	// creating a map with data
@@ -42,9 +96,538 @@ func (m *Repository) About(w http.ResponseWriter, r *http.Request){
 	stringMap["test"] = "Hello, again"
 
 	// passing the map with data by matching the fields
-	render.RenderTemplate(w, "about.page.tmpl", &models.TemplateData{
+	render.RenderTemplate(w, r, "about.page.tmpl", &models.TemplateData{
 		StringMap: stringMap, 
+		OpenGraph: &models.OpenGraph{
+			Title:       "About us",
+			Description: "Who we are and what this site is about",
+		},
+	})
+}
+
+func init() { Register(http.MethodGet, "/calc", page((*Repository).Calc)) }
+
+// calcSymbols are the operations /calc knows, with the symbol shown on the page
+var calcSymbols = map[string]string{"add": "+", "sub": "-", "mul": "×", "div": "÷"}
+
+// Calc is the interactive version of the about page's 2 + 2, eg: /calc?a=6&b=3&op=div.
+// Without any params it only shows the form, a bad number or a divide by zero is shown with a 400.
+func (m *Repository) Calc(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, b, op := q.Get("a"), q.Get("b"), q.Get("op")
+
+	// every key the template reads is set, an empty symbol means there is no result to show
+	td := &models.TemplateData{
+		StringMap: map[string]string{"a": a, "b": b, "op": op, "symbol": ""},
+		FloatMap:  map[string]float64{},
+	}
+
+	if a == "" && b == "" && op == "" {
+		render.RenderTemplate(w, r, "calc.page.tmpl", td)
+		return
+	}
+
+	result, err := calculate(a, b, op)
+	if err != nil {
+		td.Error = err.Error()
+		render.RenderTemplateStatus(w, r, "calc.page.tmpl", td, http.StatusBadRequest)
+		return
+	}
+
+	td.FloatMap["result"] = result
+	td.StringMap["symbol"] = calcSymbols[op]
+	render.RenderTemplate(w, r, "calc.page.tmpl", td)
+}
+
+// calculate parses the two numbers and runs op on a Calculator, the error message is shown to the user
+func calculate(a, b, op string) (float64, error) {
+	x, err := parseNumber(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parseNumber(b)
+	if err != nil {
+		return 0, err
+	}
+
+	var c calc.Calculator
+	switch op {
+	case "add":
+		return c.Add(x, y), nil
+	case "sub":
+		return c.Subtract(x, y), nil
+	case "mul":
+		return c.Multiply(x, y), nil
+	case "div":
+		return c.Divide(x, y)
+	default:
+		return 0, fmt.Errorf("unknown operation %q, use add, sub, mul or div", op)
+	}
+}
+
+// parseNumber reads a finite number, strconv.ParseFloat alone would also accept "NaN" and "Inf"
+func parseNumber(s string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return n, nil
+}
+
+func init() { Register(http.MethodGet, "/contact", page((*Repository).Contact)) }
+
+// Contact shows the empty contact form
+func (m *Repository) Contact(w http.ResponseWriter, r *http.Request) {
+	render.RenderTemplate(w, r, "contact.page.tmpl", &models.TemplateData{
+		Form: forms.New(nil),
+	})
+}
+
+func init() { Register(http.MethodPost, "/contact", page((*Repository).PostContact)) }
+
+// PostContact validates the contact form, an invalid one is shown again with the errors next to the fields
+func (m *Repository) PostContact(w http.ResponseWriter, r *http.Request) {
+	if !requireFormContentType(w, r) {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		infoLog().Info("could not parse the form", "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	form.Sanitize()
+
+	var contact contactInput
+	err = decodeForm(r, form, &contact)
+	if err != nil {
+		errorLog().Error("could not decode the form", "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	form.Required("name", "email")
+	form.MinLength("name", 2)
+	form.IsEmail("email")
+
+	if !form.Valid() {
+		render.RenderTemplateStatus(w, r, "contact.page.tmpl", &models.TemplateData{
+			Form: form,
+		}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	infoLog().Info("contact message", "name", contact.Name, "email", contact.Email)
+
+	m.App.Session.Put(r.Context(), "flash", "Thanks, we will get back to you soon!")
+	m.redirect(w, r, "/contact")
+}
+
+func init() { Register(http.MethodGet, "/reservations", withError((*Repository).Reservations)) }
+
+// Reservations lists every reservation together with the form to make a new one
+func (m *Repository) Reservations(w http.ResponseWriter, r *http.Request) error {
+	return m.renderReservations(w, r, nil, http.StatusOK)
+}
+
+// renderReservations shows the list with the form, a form that didn't validate is passed in to show its errors
+func (m *Repository) renderReservations(w http.ResponseWriter, r *http.Request, form *forms.Form, status int) error {
+	reservations, err := m.DB.AllReservations(r.Context())
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	data["reservations"] = reservations
+
+	render.RenderTemplateStatus(w, r, "reservations.page.tmpl", &models.TemplateData{
+		Data: data,
+		Form: form,
+	}, status)
+	return nil
+}
+
+func init() { Register(http.MethodPost, "/reservations", page((*Repository).PostReservation)) }
+
+// PostReservation stores the reservation from the posted form and sends the user back to the list
+func (m *Repository) PostReservation(w http.ResponseWriter, r *http.Request) {
+	if !requireFormContentType(w, r) {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		infoLog().Info("could not parse the form", "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	// trim the noise (spaces, control characters) before the values are used
+	form := forms.New(r.PostForm)
+	form.Sanitize()
+
+	var input reservationInput
+	err = decodeForm(r, form, &input)
+	if err != nil {
+		errorLog().Error("could not decode the form", "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	if !form.Valid() {
+		err = m.renderReservations(w, r, form, http.StatusUnprocessableEntity)
+		if err != nil {
+			errorLog().Error("could not list the reservations", "path", r.URL.Path, "error", err)
+			writeError(w, r, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	reservation, err := m.DB.CreateReservation(r.Context(), input.reservation())
+	if err != nil {
+		errorLog().Error("could not save the reservation", "path", r.URL.Path, "error", err)
+		writeError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	// the confirmation email is slow, so it is sent in the background and the user is redirected right away
+	err = m.Jobs.Submit(func(ctx context.Context) error {
+		return sendConfirmationEmail(ctx, reservation)
 	})
+	if err != nil {
+		errorLog().Error("could not queue the confirmation email", "reservation_id", reservation.ID, "error", err)
+	}
+
+	// shown once on the page we redirect to
+	m.App.Session.Put(r.Context(), "flash", "Your reservation was saved!")
+	m.redirect(w, r, "/reservations")
+}
+
+// contactInput is what the contact form posts
+type contactInput struct {
+	Name    string `form:"name"`
+	Email   string `form:"email"`
+	Message string `form:"message"`
+}
+
+// reservationInput is what the reservation form and the api post. The id and created_at are the store's to set:
+// clients posting back a reservation they read from the api may send them, they are accepted and thrown away.
+type reservationInput struct {
+	FirstName string          `json:"first_name" form:"first_name"`
+	LastName  string          `json:"last_name" form:"last_name"`
+	Email     string          `json:"email" form:"email"`
+	Phone     string          `json:"phone" form:"phone"`
+	ID        json.RawMessage `json:"id"`
+	CreatedAt json.RawMessage `json:"created_at"`
 }
 
+// reservation is the reservation to store, only with the fields a client may set
+func (in reservationInput) reservation() models.Reservation {
+	return models.Reservation{
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+		Email:     in.Email,
+		Phone:     in.Phone,
+	}
+}
+
+// decodeForm fills dst from the posted form (see forms.DecodeForm). Values that can't be converted are added to
+// the form's errors so the form is shown again with them, only a dst DecodeForm can't handle is returned.
+func decodeForm(r *http.Request, form *forms.Form, dst interface{}) error {
+	err := forms.DecodeForm(r, dst)
+	var decodeErr *forms.DecodeError
+	if errors.As(err, &decodeErr) {
+		for field, messages := range decodeErr.Errors {
+			for _, message := range messages {
+				form.Errors.Add(field, message)
+			}
+		}
+		return nil
+	}
+	return err
+}
 
+// sendConfirmationEmail sends the reservation confirmation, there is no mail server yet so it is only logged
+func sendConfirmationEmail(ctx context.Context, res models.Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if res.Email == "" {
+		return nil
+	}
+	infoLog().Info("sending confirmation email", "reservation_id", res.ID, "email", res.Email)
+	return nil
+}
+
+func init() { Register(http.MethodGet, "/api/reservations", withError((*Repository).ReservationsJSON)) }
+
+// ReservationsJSON returns one page of reservations as JSON, the page is picked with ?page=2&per_page=10
+func (m *Repository) ReservationsJSON(w http.ResponseWriter, r *http.Request) error {
+	opts, err := listquery.Parse(r.URL.Query(), reservationListFields)
+	if err != nil {
+		render.RenderJSONError(w, http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	reservations, err := m.DB.AllReservations(r.Context())
+	if err != nil {
+		return err
+	}
+	reservations = filterReservations(reservations, opts.Filters)
+	sortReservations(reservations, opts.Sort)
+
+	// a huge per_page would make the client wait for (and us build) the whole list at once
+	perPage := min(queryInt(r, "per_page", defaultPerPage), maxPerPage)
+
+	// Paginate moves a page past the end to the last page, so the slice below is always in range
+	info, err := paginate.Paginate(len(reservations), queryInt(r, "page", 1), perPage)
+	if err != nil {
+		return err
+	}
+
+	meta := models.Pagination{
+		Page:       info.CurrentPage,
+		PerPage:    info.Limit,
+		Total:      len(reservations),
+		TotalPages: info.TotalPages,
+	}
+
+	end := min(info.Offset+info.Limit, len(reservations))
+	render.RenderJSONList(w, http.StatusOK, reservations[info.Offset:end], meta)
+	return nil
+}
+
+// the page size of /api/reservations when the client doesn't send per_page, and the biggest one it may ask for
+const (
+	defaultPerPage = 10
+	maxPerPage     = 100
+)
+
+// reservationListFields are the fields /api/reservations can be sorted and filtered by
+var reservationListFields = listquery.Allowed{
+	Sort:    []string{"id", "first_name", "last_name", "created_at"},
+	Filters: []string{"email", "last_name"},
+}
+
+// reservationField returns the value of the field used for sorting and filtering
+func reservationField(res models.Reservation, field string) string {
+	switch field {
+	case "id":
+		return fmt.Sprintf("%020d", res.ID)
+	case "first_name":
+		return res.FirstName
+	case "last_name":
+		return res.LastName
+	case "email":
+		return res.Email
+	case "created_at":
+		return res.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// filterReservations keeps the reservations matching every filter
+func filterReservations(reservations []models.Reservation, filters map[string]string) []models.Reservation {
+	if len(filters) == 0 {
+		return reservations
+	}
+	var matching []models.Reservation
+	for _, res := range reservations {
+		ok := true
+		for field, value := range filters {
+			if !strings.EqualFold(reservationField(res, field), value) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matching = append(matching, res)
+		}
+	}
+	return matching
+}
+
+// sortReservations sorts by the fields in order, the next field is only looked at when the previous ones are equal
+func sortReservations(reservations []models.Reservation, sortBy []listquery.SortField) {
+	slices.SortStableFunc(reservations, func(a, b models.Reservation) int {
+		for _, sf := range sortBy {
+			c := strings.Compare(reservationField(a, sf.Field), reservationField(b, sf.Field))
+			if sf.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+func init() { Register(http.MethodGet, "/api/reservations/{id}", withError((*Repository).ReservationJSON)) }
+
+// ReservationJSON answers with the reservation whose id is in the path
+func (m *Repository) ReservationJSON(w http.ResponseWriter, r *http.Request) error {
+	var params struct {
+		ID int `param:"id"`
+	}
+	err := BindPathParams(r, &params)
+	if err != nil {
+		return err
+	}
+
+	reservations, err := m.DB.AllReservations(r.Context())
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if reservation.ID == params.ID {
+			render.RenderJSONSuccess(w, http.StatusOK, reservation, "")
+			return nil
+		}
+	}
+
+	render.RenderJSONError(w, http.StatusNotFound, "reservation not found")
+	return nil
+}
+
+func init() { Register(http.MethodPost, "/api/reservations", page((*Repository).PostReservationJSON)) }
+
+// PostReservationJSON creates a reservation from a JSON body and answers with the stored reservation
+func (m *Repository) PostReservationJSON(w http.ResponseWriter, r *http.Request) {
+	var input reservationInput
+	err := render.ReadJSON(w, r, &input)
+	if err != nil {
+		render.RenderJSONError(w, render.ReadJSONStatus(err), err.Error())
+		return
+	}
+
+	// the client gets back what was stored, with the id and created_at the store gave it
+	reservation, err := m.DB.CreateReservation(r.Context(), input.reservation())
+	if err != nil {
+		errorLog().Error("could not save the reservation", "path", r.URL.Path, "error", err)
+		render.RenderJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	err = m.Jobs.Submit(func(ctx context.Context) error {
+		return sendConfirmationEmail(ctx, reservation)
+	})
+	if err != nil {
+		errorLog().Error("could not queue the confirmation email", "reservation_id", reservation.ID, "error", err)
+	}
+
+	render.RenderJSONSuccess(w, http.StatusCreated, reservation, "Reservation created")
+}
+
+// queryInt reads a positive integer from the query string, falling back to def when it is missing or invalid
+func queryInt(r *http.Request, key string, def int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+// sitemapExcludePrefixes are the route prefixes that should never show up in the sitemap (private or non-page routes).
+var sitemapExcludePrefixes = []string{"/admin", "/api", "/healthz", "/readyz", "/debug", "/static", "/metrics", "/sitemap.xml"}
+
+// sitemapURL is a single <url> entry of the sitemap
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// sitemapURLSet is the root <urlset> element of the sitemap
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// NotFound is the page for the paths that have no route, the api gets the JSON error instead
+func (m *Repository) NotFound(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		render.RenderJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	render.RenderTemplateStatus(w, r, "notfound.page.tmpl", &models.TemplateData{}, http.StatusNotFound)
+}
+
+func init() { Register(http.MethodGet, "/admin", withError((*Repository).AdminDashboard)) }
+
+// AdminDashboard is the start page of the admin section, routes() puts every /admin route behind the admin's basic auth
+func (m *Repository) AdminDashboard(w http.ResponseWriter, r *http.Request) error {
+	reservations, err := m.DB.AllReservations(r.Context())
+	if err != nil {
+		return err
+	}
+
+	render.RenderTemplate(w, r, "admin.page.tmpl", models.NewTemplateData().
+		WithInt("reservations", len(reservations)).
+		Build())
+	return nil
+}
+
+func init() { Register(http.MethodGet, "/debug/panic", page((*Repository).Panic)) }
+
+// Panic panics on purpose so the error page of the recover middleware can be seen, it is a 404 in production
+func (m *Repository) Panic(w http.ResponseWriter, r *http.Request) {
+	if m.App.InProduction {
+		http.NotFound(w, r)
+		return
+	}
+	panic("handlers: /debug/panic was called")
+}
+
+func init() { Register(http.MethodGet, "/sitemap.xml", page((*Repository).Sitemap)) }
+
+// Sitemap walks the chi router that is serving this request and writes a sitemap.xml with every public GET route
+func (m *Repository) Sitemap(w http.ResponseWriter, r *http.Request) {
+	urlSet := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	// chi stores the router that matched the request in the route context, so we can walk all of its registered routes
+	routes := chi.RouteContext(r.Context()).Routes
+	err := chi.Walk(routes, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		// only plain GET pages belong to the sitemap, routes with params or wildcards are skipped
+		if method != http.MethodGet || strings.ContainsAny(route, "{*") {
+			return nil
+		}
+		for _, prefix := range sitemapExcludePrefixes {
+			if strings.HasPrefix(route, prefix) {
+				return nil
+			}
+		}
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{Loc: strings.TrimSuffix(m.App.BaseURL, "/") + route})
+		return nil
+	})
+	if err != nil {
+		errorLog().Error("could not walk the routes", "path", r.URL.Path, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		errorLog().Error("could not encode the sitemap", "path", r.URL.Path, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// requireFormContentType should be called at the top of every POST form handler. It returns false and writes a
+// 415 Unsupported Media Type unless the request body is a url-encoded or multipart form, so a JSON body never reaches ParseForm.
+func requireFormContentType(w http.ResponseWriter, r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && (mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data") {
+		return true
+	}
+
+	writeError(w, r, http.StatusUnsupportedMediaType)
+	return false
+}
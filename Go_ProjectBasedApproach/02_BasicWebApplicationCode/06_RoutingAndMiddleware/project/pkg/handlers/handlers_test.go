@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/pkg/render"
+	"github.com/rahulrai17/porject/pkg/repository"
+	"github.com/rahulrai17/porject/pkg/worker"
+)
+
+// testApp is the config the handlers run with in the tests, the templates are the real ones of the app
+var testApp config.AppConfig
+
+func TestMain(m *testing.M) {
+	testApp.TemplateDirs = []string{"../../templates"}
+	testApp.InfoLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+	testApp.ErrorLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+	testApp.Session = scs.New()
+	render.NewTemplates(&testApp)
+
+	// a broken template stops the tests right away instead of failing every one of them
+	_, err := render.CreateTemplateCache()
+	if err != nil {
+		fmt.Println("cannot create template cache:", err)
+		os.Exit(1)
+	}
+
+	jobs := worker.New(1, 10)
+	NewHandlers(NewRepo(&testApp, repository.NewMemoryStore(), jobs))
+
+	code := m.Run()
+	jobs.Shutdown(context.Background())
+	os.Exit(code)
+}
+
+// withStore gives the test its own store, so the reservations of one test don't show up in another
+func withStore(t *testing.T, store repository.DataStore) {
+	t.Helper()
+	old := Repo.DB
+	Repo.DB = store
+	t.Cleanup(func() { Repo.DB = old })
+}
+
+// storeWith returns a memory store holding n reservations
+func storeWith(t *testing.T, n int) *repository.MemoryStore {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	for i := 1; i <= n; i++ {
+		_, err := store.CreateReservation(context.Background(), models.Reservation{FirstName: fmt.Sprintf("guest %d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+// getRoutes builds a router from every registered route like routes() in main does, without the app's middleware
+// (only the session, which the flash messages need) so each test only exercises the handlers
+func getRoutes() http.Handler {
+	mux := chi.NewRouter()
+	mux.Use(testApp.Session.LoadAndSave)
+	for _, route := range Routes() {
+		mux.With(route.Middleware...).Method(route.Method, route.Pattern, route.Handler)
+	}
+	mux.NotFound(Repo.NotFound)
+	return mux
+}
+
+func TestHandlers(t *testing.T) {
+	withStore(t, storeWith(t, 3))
+
+	tests := []struct {
+		name           string
+		url            string
+		method         string
+		expectedStatus int
+	}{
+		{"home", "/home", http.MethodGet, http.StatusOK},
+		{"about", "/about", http.MethodGet, http.StatusOK},
+		{"calc", "/calc?a=6&b=3&op=div", http.MethodGet, http.StatusOK},
+		{"calc divide by zero", "/calc?a=6&b=0&op=div", http.MethodGet, http.StatusBadRequest},
+		{"contact", "/contact", http.MethodGet, http.StatusOK},
+		{"reservations", "/reservations", http.MethodGet, http.StatusOK},
+		{"admin", "/admin", http.MethodGet, http.StatusOK},
+		{"sitemap", "/sitemap.xml", http.MethodGet, http.StatusOK},
+		{"api list", "/api/reservations", http.MethodGet, http.StatusOK},
+		{"api reservation", "/api/reservations/2", http.MethodGet, http.StatusOK},
+		{"api unknown reservation", "/api/reservations/99", http.MethodGet, http.StatusNotFound},
+		{"api bad id", "/api/reservations/abc", http.MethodGet, http.StatusBadRequest},
+		{"unknown page", "/nope", http.MethodGet, http.StatusNotFound},
+		{"wrong method", "/home", http.MethodDelete, http.StatusMethodNotAllowed},
+	}
+
+	ts := httptest.NewServer(getRoutes())
+	defer ts.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.url, resp.StatusCode, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestReservationsJSONPagination(t *testing.T) {
+	withStore(t, storeWith(t, 25))
+
+	tests := []struct {
+		name      string
+		query     string
+		wantPage  int
+		wantPer   int
+		wantPages int
+		wantIDs   []int
+	}{
+		{"defaults", "", 1, 10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"last page", "?page=3", 3, 10, 3, []int{21, 22, 23, 24, 25}},
+		{"page past the end is the last page", "?page=99", 3, 10, 3, []int{21, 22, 23, 24, 25}},
+		{"huge page", "?page=9223372036854775807", 3, 10, 3, []int{21, 22, 23, 24, 25}},
+		{"zero page", "?page=0", 1, 10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"negative page", "?page=-2", 1, 10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"not a number", "?page=two&per_page=five", 1, 10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"per page", "?page=2&per_page=20", 2, 20, 2, []int{21, 22, 23, 24, 25}},
+		{"huge per page is capped", "?page=2&per_page=9223372036854775807", 1, 100, 1, nil},
+		{"zero per page", "?per_page=0", 1, 10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/reservations"+tt.query, nil)
+			withError((*Repository).ReservationsJSON).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (body %s)", rr.Code, http.StatusOK, rr.Body)
+			}
+
+			var body struct {
+				Data []models.Reservation `json:"data"`
+				Meta models.Pagination    `json:"meta"`
+			}
+			err := json.Unmarshal(rr.Body.Bytes(), &body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := models.Pagination{Page: tt.wantPage, PerPage: tt.wantPer, Total: 25, TotalPages: tt.wantPages}
+			if body.Meta != want {
+				t.Errorf("meta = %+v, want %+v", body.Meta, want)
+			}
+
+			// the capped per_page returns everything on its single page
+			wantIDs := tt.wantIDs
+			if wantIDs == nil {
+				for i := 1; i <= 25; i++ {
+					wantIDs = append(wantIDs, i)
+				}
+			}
+			if len(body.Data) != len(wantIDs) {
+				t.Fatalf("got %d reservations, want %d", len(body.Data), len(wantIDs))
+			}
+			for i, res := range body.Data {
+				if res.ID != wantIDs[i] {
+					t.Errorf("data[%d].ID = %d, want %d", i, res.ID, wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReservationsJSONEmpty(t *testing.T) {
+	withStore(t, repository.NewMemoryStore())
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/reservations?page=5", nil)
+	withError((*Repository).ReservationsJSON).ServeHTTP(rr, req)
+
+	want := `{"data":[],"meta":{"page":1,"per_page":10,"total":0,"total_pages":1}}`
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("got %d %s, want 200 %s", rr.Code, rr.Body, want)
+	}
+}
+
+// failingStore is a DataStore whose every call fails, like a database that went away
+type failingStore struct{}
+
+func (failingStore) AllReservations(ctx context.Context) ([]models.Reservation, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingStore) CreateReservation(ctx context.Context, res models.Reservation) (models.Reservation, error) {
+	return models.Reservation{}, errors.New("connection refused")
+}
+
+func TestReservationsList(t *testing.T) {
+	withStore(t, storeWith(t, 2))
+
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/reservations", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rr.Code)
+	}
+	for _, want := range []string{"guest 1", "guest 2"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("the page doesn't list %q", want)
+		}
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	withStore(t, failingStore{})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+	}{
+		{"list page", http.MethodGet, "/reservations", "", ""},
+		{"create from the form", http.MethodPost, "/reservations", "application/x-www-form-urlencoded", "first_name=Rahul"},
+		{"api list", http.MethodGet, "/api/reservations", "", ""},
+		{"api create", http.MethodPost, "/api/reservations", "application/json", `{"first_name":"Rahul"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			getRoutes().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", rr.Code)
+			}
+			// the visitor never sees what went wrong inside
+			if strings.Contains(rr.Body.String(), "connection refused") {
+				t.Errorf("the store error leaked: %q", rr.Body)
+			}
+		})
+	}
+}
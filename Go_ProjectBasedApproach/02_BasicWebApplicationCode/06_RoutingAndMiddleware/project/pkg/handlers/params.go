@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ErrBadParam is returned by BindPathParams when a path param can't be converted, the handler adapter answers it with a 400
+var ErrBadParam = errors.New("bad path param")
+
+// errUnsupportedType is returned by setField for a field kind it can't fill, that is a bug in the struct and not
+// something the visitor sent, so it is not an ErrBadParam
+var errUnsupportedType = errors.New("unsupported type")
+
+// BindPathParams fills the fields of the struct dst points to with the URL params of the route, the field's
+// `param:"id"` tag names the param. Strings, bools, ints, uints and floats are supported, eg:
+//
+//	var p struct {
+//		ID   int    `param:"id"`
+//		Slug string `param:"slug"`
+//	}
+//	err := BindPathParams(r, &p) // for /posts/{id}/{slug}
+func BindPathParams(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("BindPathParams: dst must be a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := t.Field(i).Tag.Lookup("param")
+		if !ok || name == "" {
+			continue
+		}
+
+		raw := chi.URLParam(r, name)
+		err := setField(v.Field(i), raw)
+		if errors.Is(err, errUnsupportedType) {
+			return fmt.Errorf("BindPathParams: field %s %w", t.Field(i).Name, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%w %q: %q %v", ErrBadParam, name, raw, err)
+		}
+	}
+	return nil
+}
+
+// setField converts raw to the type of the field and stores it
+func setField(f reflect.Value, raw string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("is not a bool")
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("is not a valid integer")
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("is not a valid positive integer")
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, f.Type().Bits())
+		if err != nil {
+			return errors.New("is not a valid number")
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("has the %w %s", errUnsupportedType, f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// post is what /posts/{id}/{slug} binds into
+type post struct {
+	ID     int     `param:"id"`
+	Slug   string  `param:"slug"`
+	Draft  bool    `param:"draft"`
+	Score  float64 `param:"score"`
+	Ignore string
+}
+
+// bind runs BindPathParams for the request path on the route pattern
+func bind(t *testing.T, pattern, path string, dst interface{}) error {
+	t.Helper()
+	var err error
+	mux := chi.NewRouter()
+	mux.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+		err = BindPathParams(r, dst)
+	})
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("%s did not match %s", path, pattern)
+	}
+	return err
+}
+
+func TestBindPathParams(t *testing.T) {
+	var p post
+	err := bind(t, "/posts/{id}/{slug}/{draft}/{score}", "/posts/42/hello-world/true/4.5", &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := post{ID: 42, Slug: "hello-world", Draft: true, Score: 4.5}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+}
+
+func TestBindPathParamsBadValue(t *testing.T) {
+	var p post
+	err := bind(t, "/posts/{id}/{slug}", "/posts/abc/hello", &p)
+	if !errors.Is(err, ErrBadParam) {
+		t.Fatalf("err = %v, want ErrBadParam", err)
+	}
+	if !strings.Contains(err.Error(), `"id"`) || !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("err = %q, want the param and its value named", err)
+	}
+
+	// not a pointer to a struct is a programming error, not a bad request
+	err = bind(t, "/posts/{id}", "/posts/1", p)
+	if err == nil || errors.Is(err, ErrBadParam) {
+		t.Errorf("err = %v, want a plain error for a non-pointer dst", err)
+	}
+}
+
+func TestBindPathParamsUnsupportedType(t *testing.T) {
+	var p struct {
+		Tags []string `param:"tags"`
+	}
+	err := bind(t, "/posts/{tags}", "/posts/a,b", &p)
+	if err == nil || errors.Is(err, ErrBadParam) {
+		t.Fatalf("err = %v, want a plain error for a field BindPathParams can't fill", err)
+	}
+	if !strings.Contains(err.Error(), "Tags") || !strings.Contains(err.Error(), "[]string") {
+		t.Errorf("err = %q, want the field and its type named", err)
+	}
+
+	// the struct is wrong, not the request, so the visitor gets a 500 and not a 400
+	mux := chi.NewRouter()
+	mux.Method(http.MethodGet, "/api/posts/{tags}", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return BindPathParams(r, &p)
+	}))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/posts/a,b", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+}
+
+func TestReservationJSONBadID(t *testing.T) {
+	withStore(t, storeWith(t, 2))
+
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reservations/abc", nil))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"status":400`) {
+		t.Errorf("got %d %q, want the JSON 400", rr.Code, rr.Body)
+	}
+
+	rr = httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reservations/2", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rr.Code)
+	}
+}
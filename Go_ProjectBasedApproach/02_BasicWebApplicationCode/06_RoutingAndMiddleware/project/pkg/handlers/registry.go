@@ -0,0 +1,47 @@
+package handlers
+
+import "net/http"
+
+// Route is one handler registered with Register, routes() in main builds the router from all of them
+type Route struct {
+	Method     string
+	Pattern    string
+	Handler    http.Handler
+	Middleware []func(http.Handler) http.Handler // applied to this route only
+}
+
+// registry holds every registered route in the order they were registered
+var registry []Route
+
+// Register adds a route to the registry. Handlers register themselves next to their own definition (in an init func),
+// so adding a handler doesn't need a second edit in routes().
+func Register(method, pattern string, handler http.Handler, middleware ...func(http.Handler) http.Handler) {
+	registry = append(registry, Route{
+		Method:     method,
+		Pattern:    pattern,
+		Handler:    handler,
+		Middleware: middleware,
+	})
+}
+
+// Routes returns a copy of all the registered routes
+func Routes() []Route {
+	out := make([]Route, len(registry))
+	copy(out, registry)
+	return out
+}
+
+// page turns a Repository method expression (eg: (*Repository).Home) into a handler. Repo is only looked up when a request
+// comes in, since the init funcs that register the routes run before main has created the repository.
+func page(f func(*Repository, http.ResponseWriter, *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f(Repo, w, r)
+	})
+}
+
+// withError is page for the handlers that return an error, the error is turned into a response by HandlerFunc
+func withError(f func(*Repository, http.ResponseWriter, *http.Request) error) http.Handler {
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return f(Repo, w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRegister(t *testing.T) {
+	old := registry
+	t.Cleanup(func() { registry = old })
+
+	tag := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Route-Middleware", "ran")
+			next.ServeHTTP(w, r)
+		})
+	}
+	Register(http.MethodGet, "/registered/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chi.URLParam(r, "id")))
+	}), tag)
+
+	routes := Routes()
+	last := routes[len(routes)-1]
+	if len(routes) != len(old)+1 || last.Method != http.MethodGet || last.Pattern != "/registered/{id}" || len(last.Middleware) != 1 {
+		t.Fatalf("the registered route is %+v", last)
+	}
+
+	// Routes hands out a copy, changing it doesn't change the registry
+	routes[len(routes)-1].Pattern = "/changed"
+	if Routes()[len(routes)-1].Pattern != "/registered/{id}" {
+		t.Error("Routes returned the registry itself")
+	}
+
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/registered/42", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "42" {
+		t.Errorf("got %d %q, want the registered handler", rr.Code, rr.Body)
+	}
+	if rr.Header().Get("X-Route-Middleware") != "ran" {
+		t.Error("the route's own middleware did not run")
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSitemap(t *testing.T) {
+	old := testApp.BaseURL
+	testApp.BaseURL = "https://example.com/"
+	t.Cleanup(func() { testApp.BaseURL = old })
+
+	rr := httptest.NewRecorder()
+	getRoutes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/xml" {
+		t.Errorf("Content-Type = %q, want application/xml", got)
+	}
+	if !strings.HasPrefix(rr.Body.String(), xml.Header) {
+		t.Error("the sitemap has no XML header")
+	}
+
+	var set sitemapURLSet
+	err := xml.Unmarshal(rr.Body.Bytes(), &set)
+	if err != nil {
+		t.Fatalf("the sitemap is not valid XML: %v", err)
+	}
+	var locs []string
+	for _, u := range set.URLs {
+		locs = append(locs, u.Loc)
+	}
+
+	for _, want := range []string{"https://example.com/home", "https://example.com/about", "https://example.com/contact"} {
+		if !slices.Contains(locs, want) {
+			t.Errorf("%s is missing from %v", want, locs)
+		}
+	}
+	for _, loc := range locs {
+		path := strings.TrimPrefix(loc, "https://example.com")
+		for _, prefix := range sitemapExcludePrefixes {
+			if strings.HasPrefix(path, prefix) {
+				t.Errorf("%s is excluded but listed", loc)
+			}
+		}
+		if strings.ContainsAny(path, "{*") {
+			t.Errorf("%s has a route param", loc)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkTimeout is the longest all readiness checks together may take, a hanging dependency counts as failing
+const checkTimeout = 2 * time.Second
+
+// Checker is one dependency the app needs before it can serve traffic (eg: the database)
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc turns a function into a Checker
+func CheckerFunc(name string, check func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, check: check}
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string                    { return c.name }
+func (c checkerFunc) Check(ctx context.Context) error { return c.check(ctx) }
+
+// Registry holds the readiness checks and the time the app started
+type Registry struct {
+	started time.Time
+
+	mu       sync.RWMutex
+	checkers []Checker
+}
+
+// New creates an empty registry, the uptime is counted from now
+func New() *Registry {
+	return &Registry{started: time.Now()}
+}
+
+// Register adds a readiness check
+func (reg *Registry) Register(c Checker) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.checkers = append(reg.checkers, c)
+}
+
+// Check runs every check and returns the error message of each failing one by name, it is empty when all pass
+func (reg *Registry) Check(ctx context.Context) map[string]string {
+	reg.mu.RLock()
+	checkers := append([]Checker(nil), reg.checkers...)
+	reg.mu.RUnlock()
+
+	failed := map[string]string{}
+	for _, c := range checkers {
+		if err := c.Check(ctx); err != nil {
+			failed[c.Name()] = err.Error()
+		}
+	}
+	return failed
+}
+
+// LiveHandler answers /healthz: the process is up and can answer requests, nothing else is checked
+func (reg *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "ok",
+			"uptime": time.Since(reg.started).Round(time.Second).String(),
+		})
+	})
+}
+
+// ReadyHandler answers /readyz: 200 when every check passes, otherwise 503 with the failing checks,
+// so a load balancer stops sending traffic until the dependencies are back
+func (reg *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		failed := reg.Check(ctx)
+		if len(failed) > 0 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status": "unavailable",
+				"failed": failed,
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
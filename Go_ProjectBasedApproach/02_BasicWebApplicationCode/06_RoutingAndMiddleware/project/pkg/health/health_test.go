@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// get serves one request and decodes the JSON body
+func get(t *testing.T, h http.Handler) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("the body is not JSON: %q", rr.Body)
+	}
+	return rr, body
+}
+
+// passing is a check that always passes
+var passing = CheckerFunc("templates", func(ctx context.Context) error { return nil })
+
+func TestLiveHandler(t *testing.T) {
+	reg := New()
+	// a failing dependency doesn't make the process unhealthy
+	reg.Register(CheckerFunc("database", func(ctx context.Context) error { return errors.New("down") }))
+
+	rr, body := get(t, reg.LiveHandler())
+	if rr.Code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("got %d %v, want 200 ok", rr.Code, body)
+	}
+	if body["uptime"] == nil || body["uptime"] == "" {
+		t.Errorf("no uptime in %v", body)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	reg := New()
+	reg.Register(passing)
+
+	rr, body := get(t, reg.ReadyHandler())
+	if rr.Code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("got %d %v, want 200 ok", rr.Code, body)
+	}
+	if rr.Header().Get("Cache-Control") != "no-store" {
+		t.Error("the answer can be cached")
+	}
+}
+
+func TestReadyHandlerFailing(t *testing.T) {
+	reg := New()
+	reg.Register(passing)
+	reg.Register(CheckerFunc("database", func(ctx context.Context) error { return errors.New("connection refused") }))
+
+	rr, body := get(t, reg.ReadyHandler())
+	if rr.Code != http.StatusServiceUnavailable || body["status"] != "unavailable" {
+		t.Errorf("got %d %v, want 503 unavailable", rr.Code, body)
+	}
+	failed, _ := body["failed"].(map[string]interface{})
+	if len(failed) != 1 || failed["database"] != "connection refused" {
+		t.Errorf("failed = %v, want only the database", body["failed"])
+	}
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/requestid"
+)
+
+// Client is the shared client for every outbound HTTP call of the app
+type Client struct {
+	HTTPClient *http.Client
+
+	// MaxAttempts is how often an idempotent request (GET, HEAD, PUT, DELETE...) is tried when it fails with a
+	// connection error or a 5xx, 1 turns the retries off
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, it doubles with every retry up to MaxDelay.
+	// The real wait is a random time up to that (jitter), so many clients failing together don't retry together.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// New creates a client whose whole request (connect, headers and body) must finish within timeout.
+// The transport keeps idle connections around so repeated calls to the same host reuse them.
+func New(timeout time.Duration) *Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &Client{
+		HTTPClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}
+}
+
+// Do sends the request bound to ctx, so it is cancelled together with the incoming request that caused it.
+// The request id found in ctx is copied to the outbound request, which lets us follow one call across services.
+// Idempotent requests that fail with a connection error or a 5xx are retried (see MaxAttempts), the last answer is returned.
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+
+	if id, ok := requestid.FromContext(ctx); ok && req.Header.Get(requestid.Header) == "" {
+		// WithContext makes a shallow copy, so clone the headers before changing them
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req.Header.Set(requestid.Header, id)
+	}
+
+	if !c.retryable(req) {
+		return c.HTTPClient.Do(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.HTTPClient.Do(req)
+		// a cancelled or timed out ctx is final, trying again can't help
+		if ctx.Err() != nil {
+			return resp, err
+		}
+		if attempt >= c.MaxAttempts || (err == nil && resp.StatusCode < 500) {
+			return resp, err
+		}
+
+		// this response is thrown away, reading it to the end lets the connection be reused
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// retryable reports if req may be sent again: only idempotent methods are, and the body must be readable again
+func (c *Client) retryable(req *http.Request) bool {
+	if c.MaxAttempts <= 1 {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// backoff returns how long to wait before the retry after attempt: a random time up to BaseDelay * 2^(attempt-1), capped at MaxDelay
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.BaseDelay << (attempt - 1)
+	if d <= 0 || (c.MaxDelay > 0 && d > c.MaxDelay) {
+		d = c.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// Get sends a GET request to url, see Do
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(ctx, req)
+}
+
+// StatusError is returned by DoJSON when the server answered with a 4xx or 5xx
+type StatusError struct {
+	StatusCode int
+	Body       string // the start of the response body, servers often explain the error there
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httpclient: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// DoJSON sends body (when it isn't nil) as JSON and decodes the JSON response into dst (when it isn't nil).
+// A 4xx or 5xx answer is returned as a *StatusError.
+func (c *Client) DoJSON(ctx context.Context, method, url string, body, dst interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		out, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		// a bytes.Reader lets NewRequest set GetBody, so the request can be retried
+		reqBody = bytes.NewReader(out)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(snippet)}
+	}
+	if dst == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/requestid"
+)
+
+// echoRequestID answers with the request id header it received
+func echoRequestID(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Got-ID", r.Header.Get(requestid.Header))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDoPropagatesRequestID(t *testing.T) {
+	ts := echoRequestID(t)
+	c := New(time.Second)
+
+	ctx := requestid.NewContext(context.Background(), "abc-123")
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get("Got-ID"); got != "abc-123" {
+		t.Errorf("the upstream got request id %q, want abc-123", got)
+	}
+	// the caller's request is left as it was
+	if req.Header.Get(requestid.Header) != "" {
+		t.Error("Do changed the headers of the caller's request")
+	}
+}
+
+func TestDoKeepsExplicitRequestID(t *testing.T) {
+	ts := echoRequestID(t)
+	c := New(time.Second)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set(requestid.Header, "set-by-caller")
+	resp, err := c.Do(requestid.NewContext(context.Background(), "from-ctx"), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Got-ID"); got != "set-by-caller" {
+		t.Errorf("the upstream got %q, want the caller's id", got)
+	}
+
+	// without an id in the context nothing is added
+	resp, err = c.Get(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Got-ID"); got != "" {
+		t.Errorf("the upstream got %q, want no request id", got)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	c := New(50 * time.Millisecond)
+	c.MaxAttempts = 1
+
+	start := time.Now()
+	_, err := c.Get(context.Background(), ts.URL)
+	if err == nil {
+		t.Fatal("the slow upstream did not time out")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the call took %v, the timeout is 50ms", elapsed)
+	}
+}
@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky answers the first failures requests with a 503 and the rest with the handler, attempts counts them all
+func flaky(t *testing.T, failures int32, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &attempts
+}
+
+// quickClient retries without waiting long, so the tests stay fast
+func quickClient() *Client {
+	c := New(time.Second)
+	c.BaseDelay = time.Millisecond
+	c.MaxDelay = 5 * time.Millisecond
+	return c
+}
+
+func TestGetRetriesUntilSuccess(t *testing.T) {
+	ts, attempts := flaky(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "finally")
+	})
+
+	resp, err := quickClient().Get(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK || string(body) != "finally" {
+		t.Errorf("got %d %q, want the answer of the third attempt", resp.StatusCode, body)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestGetGivesUp(t *testing.T) {
+	ts, attempts := flaky(t, 100, nil)
+
+	resp, err := quickClient().Get(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// the last answer is returned, the caller sees why it failed
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the last 503", resp.StatusCode)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want MaxAttempts (3)", got)
+	}
+}
+
+func TestNoRetryWhenNotIdempotent(t *testing.T) {
+	ts, attempts := flaky(t, 1, func(w http.ResponseWriter, r *http.Request) {})
+
+	err := quickClient().DoJSON(context.Background(), http.MethodPost, ts.URL, map[string]string{"name": "Rahul"}, nil)
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want a *StatusError with 503", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("a POST was sent %d times, want once", got)
+	}
+}
+
+func TestRetrySendsTheBodyAgain(t *testing.T) {
+	var got atomic.Value
+	ts, attempts := flaky(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got.Store(string(b))
+		io.WriteString(w, `{"id": 7}`)
+	})
+
+	var dst struct{ ID int }
+	err := quickClient().DoJSON(context.Background(), http.MethodPut, ts.URL, map[string]string{"name": "Rahul"}, &dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Load() != 2 || got.Load() != `{"name":"Rahul"}` {
+		t.Errorf("after %d attempts the server got %q, want the whole body on the retry", attempts.Load(), got.Load())
+	}
+	if dst.ID != 7 {
+		t.Errorf("decoded %+v", dst)
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	ts, attempts := flaky(t, 100, nil)
+	c := New(time.Second)
+	c.BaseDelay = time.Hour
+	c.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Get(ctx, ts.URL)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the backoff ignored the cancelled context, it took %v", elapsed)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestDoJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("headers %v", r.Header)
+		}
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error":"no such room"}`, http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{"name": "General's Quarters", "beds": 2}`)
+	}))
+	t.Cleanup(ts.Close)
+	c := quickClient()
+
+	var room struct {
+		Name string
+		Beds int
+	}
+	if err := c.DoJSON(context.Background(), http.MethodPut, ts.URL+"/rooms/1", struct{}{}, &room); err != nil {
+		t.Fatal(err)
+	}
+	if room.Name != "General's Quarters" || room.Beds != 2 {
+		t.Errorf("decoded %+v", room)
+	}
+
+	// a 4xx isn't retried and comes back with the start of the body
+	err := c.DoJSON(context.Background(), http.MethodPut, ts.URL+"/missing", struct{}{}, &room)
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound || se.Body != "{\"error\":\"no such room\"}\n" {
+		t.Errorf("err = %#v, want the 404 with its body", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	// the limit doubles with every attempt until MaxDelay, even where the shift would overflow
+	limits := map[int]time.Duration{
+		1:  10 * time.Millisecond,
+		2:  20 * time.Millisecond,
+		3:  40 * time.Millisecond,
+		4:  50 * time.Millisecond,
+		60: 50 * time.Millisecond,
+	}
+	for attempt, limit := range limits {
+		for range 100 {
+			if d := c.backoff(attempt); d < 0 || d >= limit {
+				t.Fatalf("backoff(%d) = %v, want it under %v", attempt, d, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Lifecycle starts the parts of the app (templates, database, workers...) in order and stops them in reverse order.
+// A start hook that acquires something (eg: a db pool) registers the matching stop hook once it succeeded,
+// so a failed start only releases what was really acquired.
+type Lifecycle struct {
+	start []func() error
+	stop  []func() error
+}
+
+// New creates an empty lifecycle
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// OnStart registers a hook that runs on Start, in the order they were registered
+func (l *Lifecycle) OnStart(hook func() error) {
+	l.start = append(l.start, hook)
+}
+
+// OnStop registers a hook that runs on Stop, the last registered first (like defer)
+func (l *Lifecycle) OnStop(hook func() error) {
+	l.stop = append(l.stop, hook)
+}
+
+// Start runs the start hooks one after the other. When one fails the remaining ones are skipped,
+// the stop hooks registered so far are run to roll back, and the error is returned.
+func (l *Lifecycle) Start() error {
+	for i, hook := range l.start {
+		if err := hook(); err != nil {
+			err = fmt.Errorf("lifecycle: start hook %d: %w", i+1, err)
+			return errors.Join(err, l.Stop())
+		}
+	}
+	return nil
+}
+
+// Stop runs every stop hook, the last registered first, and returns all their errors joined together.
+// A failing hook doesn't stop the others from running, and each hook runs only once.
+func (l *Lifecycle) Stop() error {
+	var errs []error
+	for i := len(l.stop) - 1; i >= 0; i-- {
+		if err := l.stop[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.stop = nil
+	return errors.Join(errs...)
+}
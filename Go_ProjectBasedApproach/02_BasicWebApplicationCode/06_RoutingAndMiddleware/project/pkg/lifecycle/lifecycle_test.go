@@ -0,0 +1,130 @@
+package lifecycle
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// record returns a hook that appends name to calls and returns err
+func record(calls *[]string, name string, err error) func() error {
+	return func() error {
+		*calls = append(*calls, name)
+		return err
+	}
+}
+
+func TestStopRunsEveryHook(t *testing.T) {
+	var calls []string
+	errDB := errors.New("db: close failed")
+	errLog := errors.New("log: flush failed")
+
+	l := New()
+	l.OnStop(record(&calls, "db", errDB))
+	l.OnStop(record(&calls, "log", errLog))
+
+	err := l.Stop()
+
+	// the last registered first, and a failing hook doesn't stop the next one
+	if !slices.Equal(calls, []string{"log", "db"}) {
+		t.Errorf("ran %v, want [log db]", calls)
+	}
+	if !errors.Is(err, errDB) || !errors.Is(err, errLog) {
+		t.Errorf("err = %v, want both errors", err)
+	}
+
+	// a second Stop (eg: a failed Start already ran them) doesn't close things twice
+	calls = nil
+	if err := l.Stop(); err != nil || len(calls) != 0 {
+		t.Errorf("second Stop ran %v and returned %v", calls, err)
+	}
+}
+
+func TestStopWithoutErrors(t *testing.T) {
+	var calls []string
+	l := New()
+	l.OnStop(record(&calls, "db", nil))
+	l.OnStop(record(&calls, "store", nil))
+
+	if err := l.Stop(); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("ran %v, want both hooks", calls)
+	}
+}
+
+func TestStartOrder(t *testing.T) {
+	var calls []string
+	l := New()
+	l.OnStart(func() error {
+		calls = append(calls, "start templates")
+		return nil
+	})
+	l.OnStart(func() error {
+		calls = append(calls, "start db")
+		l.OnStop(record(&calls, "stop db", nil))
+		return nil
+	})
+	l.OnStart(func() error {
+		calls = append(calls, "start workers")
+		l.OnStop(record(&calls, "stop workers", nil))
+		return nil
+	})
+
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start templates", "start db", "start workers", "stop workers", "stop db"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("ran %v, want %v", calls, want)
+	}
+}
+
+func TestStartRollback(t *testing.T) {
+	var calls []string
+	errMigrate := errors.New("migrations failed")
+
+	l := New()
+	l.OnStart(func() error {
+		calls = append(calls, "start db")
+		l.OnStop(record(&calls, "stop db", nil))
+		return nil
+	})
+	l.OnStart(record(&calls, "migrate", errMigrate))
+	l.OnStart(func() error {
+		calls = append(calls, "start workers")
+		l.OnStop(record(&calls, "stop workers", nil))
+		return nil
+	})
+
+	err := l.Start()
+	if !errors.Is(err, errMigrate) {
+		t.Fatalf("err = %v, want the migration error", err)
+	}
+
+	// the hooks after the failing one never ran, what did start was stopped again
+	want := []string{"start db", "migrate", "stop db"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("ran %v, want %v", calls, want)
+	}
+}
+
+func TestStartRollbackErrors(t *testing.T) {
+	errStart := errors.New("cache: build failed")
+	errStop := errors.New("db: close failed")
+
+	l := New()
+	l.OnStop(func() error { return errStop })
+	l.OnStart(func() error { return errStart })
+
+	// both the cause and the failed clean up are reported
+	err := l.Start()
+	if !errors.Is(err, errStart) || !errors.Is(err, errStop) {
+		t.Errorf("err = %v, want both errors", err)
+	}
+}
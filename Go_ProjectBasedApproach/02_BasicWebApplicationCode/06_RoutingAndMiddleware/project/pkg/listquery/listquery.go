@@ -0,0 +1,67 @@
+package listquery
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ErrSortField is returned by Parse when the sort asks for a field that isn't in the allow-list
+var ErrSortField = errors.New("listquery: sorting by this field is not allowed")
+
+// SortField is one field of the sort, ?sort=-created_at gives {Field: "created_at", Desc: true}
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions is what a list endpoint was asked for
+type ListOptions struct {
+	Sort    []SortField       // In the order they were given, the first one decides first.
+	Filters map[string]string // filter[status]=active gives "status" => "active".
+}
+
+// Allowed lists the fields a list endpoint can be sorted and filtered by.
+// The field names later end up in queries, so nothing outside these lists gets through.
+type Allowed struct {
+	Sort    []string
+	Filters []string
+}
+
+// Parse reads ?sort=field,-other&filter[status]=active from the query string.
+// A sort field that isn't allowed is an error (the client asked for an order we can't give),
+// filters that aren't allowed are ignored. An empty query gives empty options.
+func Parse(q url.Values, allowed Allowed) (ListOptions, error) {
+	opts := ListOptions{Filters: map[string]string{}}
+
+	for _, field := range strings.Split(q.Get("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sf := SortField{Field: field}
+		if name, ok := strings.CutPrefix(field, "-"); ok {
+			sf = SortField{Field: name, Desc: true}
+		}
+		if !slices.Contains(allowed.Sort, sf.Field) {
+			return ListOptions{}, fmt.Errorf("%w: %q", ErrSortField, sf.Field)
+		}
+		opts.Sort = append(opts.Sort, sf)
+	}
+
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, "]")
+		if !ok || !slices.Contains(allowed.Filters, name) || len(values) == 0 {
+			continue
+		}
+		opts.Filters[name] = values[0]
+	}
+
+	return opts, nil
+}
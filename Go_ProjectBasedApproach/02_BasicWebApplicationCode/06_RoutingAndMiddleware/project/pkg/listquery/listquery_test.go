@@ -0,0 +1,51 @@
+package listquery
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var allowed = Allowed{
+	Sort:    []string{"created_at", "last_name"},
+	Filters: []string{"status"},
+}
+
+func TestParse(t *testing.T) {
+	q, err := url.ParseQuery("sort=-created_at, last_name&filter[status]=active&filter[password]=x&filter[status=y&page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := Parse(q, allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ListOptions{
+		Sort:    []SortField{{Field: "created_at", Desc: true}, {Field: "last_name"}},
+		Filters: map[string]string{"status": "active"},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+}
+
+func TestParseDisallowedSort(t *testing.T) {
+	for _, sort := range []string{"password", "-password", "last_name,id;drop table"} {
+		_, err := Parse(url.Values{"sort": {sort}}, allowed)
+		if !errors.Is(err, ErrSortField) {
+			t.Errorf("sort=%s: err = %v, want ErrSortField", sort, err)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	opts, err := Parse(url.Values{}, allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Sort) != 0 || opts.Filters == nil || len(opts.Filters) != 0 {
+		t.Errorf("got %+v, want no sort and an empty filter map", opts)
+	}
+}
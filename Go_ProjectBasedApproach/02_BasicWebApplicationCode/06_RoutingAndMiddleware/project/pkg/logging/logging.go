@@ -0,0 +1,24 @@
+// Package logging builds the structured (log/slog) loggers of the app
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+// New returns a logger writing to w: easy to read key=value lines during development,
+// one JSON object per line in production so a log collector can search the fields
+func New(w io.Writer, inProduction bool) *slog.Logger {
+	if inProduction {
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+	return slog.New(slog.NewTextHandler(w, nil))
+}
+
+// Or returns l, or the default slog logger when l is nil, so packages keep logging before main has set the loggers up
+func Or(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
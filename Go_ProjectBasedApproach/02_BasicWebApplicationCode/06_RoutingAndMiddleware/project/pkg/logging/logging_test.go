@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewDevelopment(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, false).Error("could not execute template", "template", "home.page.tmpl", "path", "/home")
+
+	line := buf.String()
+	for _, want := range []string{"level=ERROR", `msg="could not execute template"`, "template=home.page.tmpl", "path=/home"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("%q does not contain %q", line, want)
+		}
+	}
+}
+
+func TestNewProduction(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, true).Info("contact message", "name", "Rahul", "path", "/contact")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("not one JSON object: %q", buf.String())
+	}
+	want := map[string]string{"level": "INFO", "msg": "contact message", "name": "Rahul", "path": "/contact"}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %q", key, entry[key], value)
+		}
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != slog.Default() {
+		t.Error("Or(nil) is not the default logger")
+	}
+	l := New(&bytes.Buffer{}, false)
+	if Or(l) != l {
+		t.Error("Or didn't return the given logger")
+	}
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Buckets are the upper bounds of the latency histogram. Anything slower than the last bound goes into an extra overflow bucket.
+var Buckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+}
+
+// Metrics holds the request counters of the application. Every field is updated atomically so it is safe to use from many requests at once.
+type Metrics struct {
+	requests     atomic.Int64
+	totalLatency atomic.Int64   // sum of all latencies in nanoseconds
+	buckets      []atomic.Int64 // one counter per entry in Buckets plus the overflow bucket
+
+	routesMu sync.Mutex
+	routes   map[string]int64 // requests per route pattern (eg: /page/{id}), never per raw path so the map stays small
+
+	countersMu sync.Mutex
+	counters   map[string]int64 // named event counters that aren't requests (eg: jobs_panicked)
+}
+
+// New creates an empty Metrics
+func New() *Metrics {
+	return &Metrics{
+		buckets:  make([]atomic.Int64, len(Buckets)+1),
+		routes:   map[string]int64{},
+		counters: map[string]int64{},
+	}
+}
+
+// Inc adds one to the named counter, eg: m.Inc("jobs_panicked")
+func (m *Metrics) Inc(name string) {
+	m.countersMu.Lock()
+	m.counters[name]++
+	m.countersMu.Unlock()
+}
+
+// Observe records one request to the route pattern that took d to complete
+func (m *Metrics) Observe(route string, d time.Duration) {
+	m.requests.Add(1)
+	m.totalLatency.Add(int64(d))
+	m.buckets[bucketFor(d)].Add(1)
+
+	m.routesMu.Lock()
+	m.routes[route]++
+	m.routesMu.Unlock()
+}
+
+// bucketFor returns the index of the first bucket whose upper bound is >= d
+func bucketFor(d time.Duration) int {
+	for i, bound := range Buckets {
+		if d <= bound {
+			return i
+		}
+	}
+	return len(Buckets)
+}
+
+// Bucket is the count of requests at or below an upper bound (LE is in milliseconds, -1 means the overflow bucket)
+type Bucket struct {
+	LE    float64 `json:"le_ms"`
+	Count int64   `json:"count"`
+}
+
+// Snapshot is a point in time copy of the metrics, this is what the /metrics endpoint sends back
+type Snapshot struct {
+	Requests     int64            `json:"requests"`
+	Routes       map[string]int64 `json:"routes"`
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+	Buckets      []Bucket         `json:"buckets"`
+	P50Ms        float64          `json:"p50_ms"`
+	P95Ms        float64          `json:"p95_ms"`
+	P99Ms        float64          `json:"p99_ms"`
+	Counters     map[string]int64 `json:"counters"`
+}
+
+// Snapshot reads the current counters
+func (m *Metrics) Snapshot() Snapshot {
+	s := Snapshot{Requests: m.requests.Load(), Routes: map[string]int64{}, Counters: map[string]int64{}}
+	if s.Requests > 0 {
+		s.AvgLatencyMs = durationToMs(time.Duration(m.totalLatency.Load() / s.Requests))
+	}
+
+	counts := make([]int64, len(m.buckets))
+	var total int64
+	for i := range m.buckets {
+		counts[i] = m.buckets[i].Load()
+		total += counts[i]
+
+		le := -1.0
+		if i < len(Buckets) {
+			le = durationToMs(Buckets[i])
+		}
+		s.Buckets = append(s.Buckets, Bucket{LE: le, Count: counts[i]})
+	}
+
+	m.routesMu.Lock()
+	for route, count := range m.routes {
+		s.Routes[route] = count
+	}
+	m.routesMu.Unlock()
+
+	m.countersMu.Lock()
+	for name, count := range m.counters {
+		s.Counters[name] = count
+	}
+	m.countersMu.Unlock()
+
+	s.P50Ms = percentile(counts, total, 0.50)
+	s.P95Ms = percentile(counts, total, 0.95)
+	s.P99Ms = percentile(counts, total, 0.99)
+
+	return s
+}
+
+// percentile estimates the q-th percentile as the upper bound of the bucket where the cumulative count reaches q.
+// Requests in the overflow bucket are reported as the biggest bound since we don't know how slow they really were.
+func percentile(counts []int64, total int64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	target := int64(q*float64(total) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+
+	var cumulative int64
+	for i, c := range counts {
+		cumulative += c
+		if cumulative >= target {
+			if i < len(Buckets) {
+				return durationToMs(Buckets[i])
+			}
+			break
+		}
+	}
+	return durationToMs(Buckets[len(Buckets)-1])
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Handler serves the current snapshot as JSON
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m.Snapshot())
+	})
+}
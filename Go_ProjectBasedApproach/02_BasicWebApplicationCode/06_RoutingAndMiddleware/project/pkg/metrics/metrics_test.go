@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestObserveBuckets(t *testing.T) {
+	m := New()
+	latencies := []time.Duration{
+		1 * time.Millisecond,   // <= 5ms
+		5 * time.Millisecond,   // <= 5ms, the bound belongs to its bucket
+		7 * time.Millisecond,   // <= 10ms
+		30 * time.Millisecond,  // <= 50ms
+		30 * time.Millisecond,  // <= 50ms
+		900 * time.Millisecond, // <= 1s
+		3 * time.Second,        // overflow
+	}
+	for _, d := range latencies {
+		m.Observe("/page/{id}", d)
+	}
+
+	s := m.Snapshot()
+	want := []Bucket{
+		{LE: 5, Count: 2},
+		{LE: 10, Count: 1},
+		{LE: 25, Count: 0},
+		{LE: 50, Count: 2},
+		{LE: 100, Count: 0},
+		{LE: 250, Count: 0},
+		{LE: 500, Count: 0},
+		{LE: 1000, Count: 1},
+		{LE: -1, Count: 1},
+	}
+	if len(s.Buckets) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(s.Buckets), len(want))
+	}
+	for i := range want {
+		if s.Buckets[i] != want[i] {
+			t.Errorf("bucket %d = %+v, want %+v", i, s.Buckets[i], want[i])
+		}
+	}
+
+	if s.Requests != 7 || s.Routes["/page/{id}"] != 7 {
+		t.Errorf("requests = %d, routes = %v", s.Requests, s.Routes)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	m := New()
+	// 90 fast requests, 9 slower ones and a single very slow one
+	for range 90 {
+		m.Observe("/", 3*time.Millisecond)
+	}
+	for range 9 {
+		m.Observe("/", 200*time.Millisecond)
+	}
+	m.Observe("/", 5*time.Second)
+
+	s := m.Snapshot()
+	if s.P50Ms != 5 {
+		t.Errorf("p50 = %v, want 5", s.P50Ms)
+	}
+	if s.P95Ms != 250 {
+		t.Errorf("p95 = %v, want 250", s.P95Ms)
+	}
+	// the overflow is reported as the biggest bound, we don't know how slow it really was
+	if s.P99Ms != 250 {
+		t.Errorf("p99 = %v, want 250", s.P99Ms)
+	}
+	wantAvg := float64(90*3+9*200+5000) / 100
+	if s.AvgLatencyMs != wantAvg {
+		t.Errorf("avg = %v, want %v", s.AvgLatencyMs, wantAvg)
+	}
+}
+
+func TestPercentilesOverflow(t *testing.T) {
+	m := New()
+	m.Observe("/", 2*time.Second)
+
+	if s := m.Snapshot(); s.P50Ms != 1000 || s.P99Ms != 1000 {
+		t.Errorf("p50 = %v, p99 = %v, want the biggest bound", s.P50Ms, s.P99Ms)
+	}
+}
+
+func TestEmptySnapshot(t *testing.T) {
+	s := New().Snapshot()
+	if s.Requests != 0 || s.AvgLatencyMs != 0 || s.P50Ms != 0 || s.P99Ms != 0 {
+		t.Errorf("got %+v, want zeros", s)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.Observe("/home", 20*time.Millisecond)
+	m.Inc("jobs_panicked")
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var s Snapshot
+	err := json.Unmarshal(rr.Body.Bytes(), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Requests != 1 || s.Routes["/home"] != 1 || s.Counters["jobs_panicked"] != 1 || s.P50Ms != 25 {
+		t.Errorf("got %+v", s)
+	}
+}
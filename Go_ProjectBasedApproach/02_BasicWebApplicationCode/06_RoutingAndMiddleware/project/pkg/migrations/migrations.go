@@ -0,0 +1,182 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// files holds the migrations, they are named <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// migration is one schema change with the sql to apply and to revert it
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// load reads the embedded files and returns the migrations sorted by version
+func load() ([]migration, error) {
+	paths, err := fs.Glob(files, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, path := range paths {
+		base := strings.TrimPrefix(path, "sql/")
+
+		// split "0001_create_reservations.up.sql" into the version and the rest
+		prefix, rest, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migrations: %s is not named <version>_<name>.up.sql", base)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: %s has an invalid version: %w", base, err)
+		}
+
+		content, err := files.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			m.name = strings.TrimSuffix(rest, ".up.sql")
+			m.up = string(content)
+		case strings.HasSuffix(rest, ".down.sql"):
+			m.down = string(content)
+		default:
+			return nil, fmt.Errorf("migrations: %s must end in .up.sql or .down.sql", base)
+		}
+	}
+
+	list := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migrations: version %d has no up migration", m.version)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+	return list, nil
+}
+
+// ensureTable creates the table that remembers which versions were applied
+func ensureTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// applied returns the versions already in schema_migrations
+func applied(db *sql.DB) (map[int]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]bool{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions[v] = true
+	}
+	return versions, rows.Err()
+}
+
+// Up applies every pending migration in order, each one inside its own transaction.
+// It stops at the first failure so the schema is never left half migrated.
+func Up(db *sql.DB) error {
+	list, err := load()
+	if err != nil {
+		return err
+	}
+	if err := ensureTable(db); err != nil {
+		return fmt.Errorf("migrations: creating schema_migrations: %w", err)
+	}
+	done, err := applied(db)
+	if err != nil {
+		return fmt.Errorf("migrations: reading schema_migrations: %w", err)
+	}
+
+	for _, m := range list {
+		if done[m.version] {
+			continue
+		}
+		err := inTx(db, m.up, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version)
+		if err != nil {
+			return fmt.Errorf("migrations: applying %d_%s: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// Down reverts the latest applied migration
+func Down(db *sql.DB) error {
+	list, err := load()
+	if err != nil {
+		return err
+	}
+	if err := ensureTable(db); err != nil {
+		return fmt.Errorf("migrations: creating schema_migrations: %w", err)
+	}
+	done, err := applied(db)
+	if err != nil {
+		return fmt.Errorf("migrations: reading schema_migrations: %w", err)
+	}
+
+	// walk backwards to find the newest applied version
+	for i := len(list) - 1; i >= 0; i-- {
+		m := list[i]
+		if !done[m.version] {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("migrations: %d_%s has no down migration", m.version, m.name)
+		}
+		err := inTx(db, m.down, `DELETE FROM schema_migrations WHERE version = $1`, m.version)
+		if err != nil {
+			return fmt.Errorf("migrations: reverting %d_%s: %w", m.version, m.name, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// inTx runs the migration sql and the schema_migrations bookkeeping statement in one transaction
+func inTx(db *sql.DB, migrationSQL string, bookkeeping string, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(migrationSQL); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(bookkeeping, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var (
+	createTable = regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)
+	selectDone  = regexp.QuoteMeta(`SELECT version FROM schema_migrations`)
+	insertDone  = regexp.QuoteMeta(`INSERT INTO schema_migrations (version) VALUES ($1)`)
+	deleteDone  = regexp.QuoteMeta(`DELETE FROM schema_migrations WHERE version = $1`)
+)
+
+// newMock returns a sqlmock database that fails the test if an expected statement didn't run
+func newMock(t *testing.T) (sqlmock.Sqlmock, func() error) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return mock, func() error { return Up(db) }
+}
+
+// expectVersions makes the schema_migrations query return the versions
+func expectVersions(mock sqlmock.Sqlmock, versions ...int) {
+	mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, v := range versions {
+		rows.AddRow(v)
+	}
+	mock.ExpectQuery(selectDone).WillReturnRows(rows)
+}
+
+func TestLoad(t *testing.T) {
+	list, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(list))
+	}
+	if list[0].version != 1 || list[0].name != "create_reservations" || !strings.Contains(list[0].up, "CREATE TABLE reservations") || !strings.Contains(list[0].down, "DROP TABLE reservations") {
+		t.Errorf("first = %+v", list[0])
+	}
+	if list[1].version != 2 || list[1].name != "index_reservations_email" {
+		t.Errorf("second = %+v", list[1])
+	}
+}
+
+func TestUp(t *testing.T) {
+	mock, up := newMock(t)
+	expectVersions(mock)
+
+	// each migration in its own transaction, together with its row in schema_migrations
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE reservations")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertDone).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX reservations_email_idx")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertDone).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpSkipsApplied(t *testing.T) {
+	mock, up := newMock(t)
+	expectVersions(mock, 1)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX reservations_email_idx")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertDone).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpNothingPending(t *testing.T) {
+	mock, up := newMock(t)
+	expectVersions(mock, 1, 2)
+
+	if err := up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	mock, up := newMock(t)
+	expectVersions(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE reservations")).WillReturnError(errors.New("permission denied"))
+	mock.ExpectRollback()
+	// the second migration is never tried
+
+	err := up()
+	if err == nil || !strings.Contains(err.Error(), "applying 1_create_reservations") || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	expectVersions(mock, 1, 2)
+
+	// only the newest applied migration is reverted
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP INDEX reservations_email_idx")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(deleteDone).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := Down(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1 @@
+DROP INDEX reservations_email_idx;
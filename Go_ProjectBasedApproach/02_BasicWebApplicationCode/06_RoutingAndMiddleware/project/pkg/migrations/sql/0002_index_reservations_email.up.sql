@@ -0,0 +1 @@
+CREATE INDEX reservations_email_idx ON reservations (email);
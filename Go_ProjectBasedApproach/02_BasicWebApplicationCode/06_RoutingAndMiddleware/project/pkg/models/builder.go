@@ -0,0 +1,68 @@
+package models
+
+import "maps"
+
+// TemplateDataBuilder builds a TemplateData one value at a time, eg:
+//
+//	td := models.NewTemplateData().WithString("title", "Hello").WithInt("count", 3).Build()
+type TemplateDataBuilder struct {
+	td TemplateData
+}
+
+// NewTemplateData starts an empty TemplateData, the maps are only created once something is put in them
+func NewTemplateData() *TemplateDataBuilder {
+	return &TemplateDataBuilder{}
+}
+
+// WithString sets StringMap[key]
+func (b *TemplateDataBuilder) WithString(key, value string) *TemplateDataBuilder {
+	if b.td.StringMap == nil {
+		b.td.StringMap = map[string]string{}
+	}
+	b.td.StringMap[key] = value
+	return b
+}
+
+// WithInt sets IntMap[key]
+func (b *TemplateDataBuilder) WithInt(key string, value int) *TemplateDataBuilder {
+	if b.td.IntMap == nil {
+		b.td.IntMap = map[string]int{}
+	}
+	b.td.IntMap[key] = value
+	return b
+}
+
+// WithFloat sets FloatMap[key]
+func (b *TemplateDataBuilder) WithFloat(key string, value float64) *TemplateDataBuilder {
+	if b.td.FloatMap == nil {
+		b.td.FloatMap = map[string]float64{}
+	}
+	b.td.FloatMap[key] = value
+	return b
+}
+
+// WithData sets Data[key]
+func (b *TemplateDataBuilder) WithData(key string, value interface{}) *TemplateDataBuilder {
+	if b.td.Data == nil {
+		b.td.Data = map[string]interface{}{}
+	}
+	b.td.Data[key] = value
+	return b
+}
+
+// WithError sets the error message shown at the top of the page
+func (b *TemplateDataBuilder) WithError(message string) *TemplateDataBuilder {
+	b.td.Error = message
+	return b
+}
+
+// Build returns the TemplateData. It gets its own copy of the maps, so using the builder afterwards
+// (eg: to build a second, similar page) doesn't change a TemplateData that was already built.
+func (b *TemplateDataBuilder) Build() *TemplateData {
+	td := b.td
+	td.StringMap = maps.Clone(b.td.StringMap)
+	td.IntMap = maps.Clone(b.td.IntMap)
+	td.FloatMap = maps.Clone(b.td.FloatMap)
+	td.Data = maps.Clone(b.td.Data)
+	return &td
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateDataBuilder(t *testing.T) {
+	td := NewTemplateData().
+		WithString("title", "Rooms").
+		WithString("lead", "Pick one").
+		WithInt("count", 3).
+		WithFloat("price", 89.5).
+		WithData("rooms", []string{"gq", "ms"}).
+		WithError("Sorry, the search failed").
+		Build()
+
+	want := &TemplateData{
+		StringMap: map[string]string{"title": "Rooms", "lead": "Pick one"},
+		IntMap:    map[string]int{"count": 3},
+		FloatMap:  map[string]float64{"price": 89.5},
+		Data:      map[string]interface{}{"rooms": []string{"gq", "ms"}},
+		Error:     "Sorry, the search failed",
+	}
+	if !reflect.DeepEqual(td, want) {
+		t.Errorf("built %+v, want %+v", td, want)
+	}
+
+	// nothing set, no maps made
+	if empty := NewTemplateData().Build(); !reflect.DeepEqual(empty, &TemplateData{}) {
+		t.Errorf("built %+v, want an empty TemplateData", empty)
+	}
+}
+
+func TestTemplateDataBuilderMapsNotShared(t *testing.T) {
+	a := NewTemplateData().WithString("title", "A")
+	b := NewTemplateData().WithString("title", "B")
+	tdA, tdB := a.Build(), b.Build()
+	if tdA.StringMap["title"] != "A" || tdB.StringMap["title"] != "B" {
+		t.Errorf("two builders share a map: %v %v", tdA.StringMap, tdB.StringMap)
+	}
+
+	// building again after more changes leaves the first result alone
+	a.WithString("title", "changed").WithInt("count", 1)
+	if tdA.StringMap["title"] != "A" || tdA.IntMap != nil {
+		t.Errorf("the built TemplateData changed with the builder: %+v", tdA)
+	}
+	tdA.StringMap["title"] = "edited"
+	if again := a.Build(); again.StringMap["title"] != "changed" {
+		t.Errorf("editing a built TemplateData changed the builder: %v", again.StringMap)
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+// OpenGraph holds the og: meta tags used by social sites to build link previews
+type OpenGraph struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+}
+
+// WithDefaults returns a copy of og where every empty field is taken from defaults
+func (og OpenGraph) WithDefaults(defaults OpenGraph) OpenGraph {
+	if og.Title == "" {
+		og.Title = defaults.Title
+	}
+	if og.Description == "" {
+		og.Description = defaults.Description
+	}
+	if og.Image == "" {
+		og.Image = defaults.Image
+	}
+	if og.URL == "" {
+		og.URL = defaults.URL
+	}
+	return og
+}
@@ -0,0 +1,9 @@
+package models
+
+// Pagination describes which slice of a list is being returned, sent as the "meta" of JSON list responses
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Reservation holds a single reservation made through the site
+type Reservation struct {
+	ID        int       `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+}
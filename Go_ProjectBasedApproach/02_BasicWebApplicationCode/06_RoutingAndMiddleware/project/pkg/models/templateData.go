@@ -1,5 +1,7 @@
 package models
 
+import "github.com/rahulrai17/porject/pkg/forms"
+
 // TemplateData struct provides different fields to accommodate various types of data that might be sent to the template.
 // TemplateData holds data sent from handlers to templates
 type TemplateData struct {
@@ -11,4 +13,52 @@ type TemplateData struct {
 	Flash     string                 // A message shown to the user, typically after a successful action.
 	Warning   string                 // A warning message to display in the UI.
 	Error     string                 // An error message to display in the UI.
+	Form      *forms.Form            // The submitted form and its validation errors, shown again when it was invalid.
+	User      *User                  // The logged in user, nil when nobody is logged in.
+	CurrentYear int                  // The current year, eg: for the copyright line in the footer.
+	Referer   string                 // The local page the user came from, empty when they came from another site.
+	OpenGraph *OpenGraph             // Link preview meta tags of the page, the empty fields fall back to the site defaults.
+	FeatureFlag func(name string) bool // Reports if a feature flag is on for this request, used by the flag template func.
+}
+
+// Merge fills the empty parts of td with the values from defaults. Values already set on td always win,
+// and defaults is never modified so the same defaults can be merged into every request.
+func (td *TemplateData) Merge(defaults *TemplateData) {
+	if defaults == nil {
+		return
+	}
+
+	td.StringMap = mergeMap(td.StringMap, defaults.StringMap)
+	td.IntMap = mergeMap(td.IntMap, defaults.IntMap)
+	td.FloatMap = mergeMap(td.FloatMap, defaults.FloatMap)
+	td.Data = mergeMap(td.Data, defaults.Data)
+
+	if td.CSRFToken == "" {
+		td.CSRFToken = defaults.CSRFToken
+	}
+	if td.Flash == "" {
+		td.Flash = defaults.Flash
+	}
+	if td.Warning == "" {
+		td.Warning = defaults.Warning
+	}
+	if td.Error == "" {
+		td.Error = defaults.Error
+	}
+}
+
+// mergeMap copies the keys of defaults that are missing in dst, creating dst if it is nil
+func mergeMap[V any](dst, defaults map[string]V) map[string]V {
+	if len(defaults) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]V, len(defaults))
+	}
+	for k, v := range defaults {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+	return dst
 }
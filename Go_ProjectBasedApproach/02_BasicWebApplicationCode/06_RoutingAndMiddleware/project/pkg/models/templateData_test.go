@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	defaults := &TemplateData{
+		StringMap: map[string]string{"site_name": "Bookings", "tagline": "Stay with us"},
+		IntMap:    map[string]int{"year": 2024},
+		Flash:     "default flash",
+	}
+	td := &TemplateData{
+		StringMap: map[string]string{"site_name": "My page"},
+		Flash:     "Saved!",
+	}
+
+	td.Merge(defaults)
+
+	// the handler's own values win, the missing ones come from the defaults
+	if td.StringMap["site_name"] != "My page" || td.StringMap["tagline"] != "Stay with us" {
+		t.Errorf("StringMap = %v", td.StringMap)
+	}
+	if td.IntMap["year"] != 2024 {
+		t.Errorf("IntMap = %v", td.IntMap)
+	}
+	if td.Flash != "Saved!" {
+		t.Errorf("Flash = %q, want the handler's", td.Flash)
+	}
+
+	// the defaults are shared by every request, merging must never change them
+	td.IntMap["year"] = 1999
+	if defaults.IntMap["year"] != 2024 || len(defaults.StringMap) != 2 {
+		t.Errorf("the defaults were changed: %+v", defaults)
+	}
+}
+
+func TestMergeNil(t *testing.T) {
+	td := &TemplateData{Flash: "Saved!"}
+	td.Merge(nil)
+	if td.Flash != "Saved!" || td.StringMap != nil {
+		t.Errorf("got %+v", td)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "slices"
+
+// User is a person who can log in to the site
+type User struct {
+	ID    int
+	Name  string
+	Email string
+	Roles []string // eg: "admin"
+}
+
+// HasRole reports if the user has the role, it is safe to call on a nil user (no one logged in)
+func (u *User) HasRole(role string) bool {
+	return u != nil && slices.Contains(u.Roles, role)
+}
@@ -0,0 +1,97 @@
+package netlimit
+
+import (
+	"log"
+	"net"
+	"sync"
+)
+
+// Listener wraps l so that one client IP can hold at most perIP connections at the same time and the whole server at most
+// total connections. Connections over a limit are closed straight away, before any request on them is read.
+// A limit of 0 means no limit.
+func Listener(l net.Listener, perIP, total int) net.Listener {
+	return &limitListener{
+		Listener: l,
+		perIP:    perIP,
+		total:    total,
+		byIP:     map[string]int{},
+	}
+}
+
+type limitListener struct {
+	net.Listener
+	perIP int
+	total int
+
+	mu   sync.Mutex
+	open int
+	byIP map[string]int
+}
+
+// Accept returns the next connection that is within the limits, refused connections are never returned
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := clientIP(c)
+		if !l.acquire(ip) {
+			log.Printf("netlimit: refusing connection from %s, too many open connections", ip)
+			c.Close()
+			continue
+		}
+		return &limitConn{Conn: c, release: func() { l.release(ip) }}, nil
+	}
+}
+
+// acquire takes a slot for ip, it reports false if ip or the server is already at its limit
+func (l *limitListener) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total > 0 && l.open >= l.total {
+		return false
+	}
+	if l.perIP > 0 && l.byIP[ip] >= l.perIP {
+		return false
+	}
+	l.open++
+	l.byIP[ip]++
+	return true
+}
+
+// release gives the slot of ip back, the map entry is removed at zero so it doesn't grow forever
+func (l *limitListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open--
+	l.byIP[ip]--
+	if l.byIP[ip] <= 0 {
+		delete(l.byIP, ip)
+	}
+}
+
+// clientIP is the ip part of the remote address of the connection
+func clientIP(c net.Conn) string {
+	host, _, err := net.SplitHostPort(c.RemoteAddr().String())
+	if err != nil {
+		return c.RemoteAddr().String()
+	}
+	return host
+}
+
+// limitConn gives its slot back when it is closed, only once even if Close is called again
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
@@ -0,0 +1,107 @@
+package netlimit
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeConn is a connection from addr that remembers if it was closed
+type fakeConn struct {
+	net.Conn
+	addr   net.Addr
+	closed atomic.Bool
+}
+
+func (c *fakeConn) RemoteAddr() net.Addr { return c.addr }
+func (c *fakeConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+// fakeListener hands out the queued connections, then fails like a closed listener
+type fakeListener struct {
+	net.Listener
+	conns []*fakeConn
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	if len(l.conns) == 0 {
+		return nil, net.ErrClosed
+	}
+	c := l.conns[0]
+	l.conns = l.conns[1:]
+	return c, nil
+}
+
+func conn(ip string) *fakeConn {
+	return &fakeConn{addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}}
+}
+
+func TestListenerPerIP(t *testing.T) {
+	a1, a2, a3, b1 := conn("10.0.0.1"), conn("10.0.0.1"), conn("10.0.0.1"), conn("10.0.0.2")
+	fake := &fakeListener{conns: []*fakeConn{a1, a2, a3, b1}}
+	l := Listener(fake, 2, 0)
+
+	var accepted []net.Conn
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				t.Fatal(err)
+			}
+			break
+		}
+		accepted = append(accepted, c)
+	}
+
+	// the third connection of 10.0.0.1 is over the cap, the other ip still gets in
+	if len(accepted) != 3 {
+		t.Fatalf("accepted %d connections, want 3", len(accepted))
+	}
+	if !a3.closed.Load() {
+		t.Error("the refused connection was left open")
+	}
+	if a1.closed.Load() || a2.closed.Load() || b1.closed.Load() {
+		t.Error("an accepted connection was closed")
+	}
+
+	// closing one gives its slot back (twice is still one slot)
+	accepted[0].Close()
+	accepted[0].Close()
+	a4, a5 := conn("10.0.0.1"), conn("10.0.0.1")
+	fake.conns = []*fakeConn{a4, a5}
+	if _, err := l.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	l.Accept()
+	if a4.closed.Load() || !a5.closed.Load() {
+		t.Errorf("after one close: a4 closed=%v a5 closed=%v, want only a5 refused", a4.closed.Load(), a5.closed.Load())
+	}
+}
+
+func TestListenerTotal(t *testing.T) {
+	c1, c2, c3 := conn("10.0.0.1"), conn("10.0.0.2"), conn("10.0.0.3")
+	l := Listener(&fakeListener{conns: []*fakeConn{c1, c2, c3}}, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Accept(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("Accept = %v, want the third connection refused", err)
+	}
+	if !c3.closed.Load() {
+		t.Error("the connection over the total was left open")
+	}
+}
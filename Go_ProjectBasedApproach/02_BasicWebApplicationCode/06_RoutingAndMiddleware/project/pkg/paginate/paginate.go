@@ -0,0 +1,47 @@
+// Package paginate works out which slice of a list a page shows, and what the pagination controls of a template need
+package paginate
+
+import "errors"
+
+// ErrInvalidPerPage is returned by Paginate when perPage is 0 or negative
+var ErrInvalidPerPage = errors.New("paginate: perPage must be greater than 0")
+
+// PageInfo describes one page of a list. Offset and Limit select the items (eg: in SQL: LIMIT Limit OFFSET Offset),
+// the rest is for the pagination controls, eg: in a template:
+//
+//	{{with index .Data "page"}}{{if .HasPrev}}<a href="?page={{add .CurrentPage -1}}">Previous</a>{{end}}{{end}}
+type PageInfo struct {
+	CurrentPage int
+	TotalPages  int
+	HasNext     bool
+	HasPrev     bool
+	Offset      int
+	Limit       int
+}
+
+// Paginate returns the page info for page of a list with total items, perPage items per page.
+// A page before the first or after the last one is moved to the first or the last page, an empty list has one (empty) page.
+func Paginate(total, page, perPage int) (PageInfo, error) {
+	if perPage <= 0 {
+		return PageInfo{}, ErrInvalidPerPage
+	}
+	if total < 0 {
+		total = 0
+	}
+
+	totalPages := (total + perPage - 1) / perPage
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	page = min(max(page, 1), totalPages)
+
+	return PageInfo{
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrev:     page > 1,
+		Offset:      (page - 1) * perPage,
+		Limit:       perPage,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package paginate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		page    int
+		perPage int
+		want    PageInfo
+	}{
+		{"first page", 25, 1, 10, PageInfo{CurrentPage: 1, TotalPages: 3, HasNext: true, HasPrev: false, Offset: 0, Limit: 10}},
+		{"middle page", 25, 2, 10, PageInfo{CurrentPage: 2, TotalPages: 3, HasNext: true, HasPrev: true, Offset: 10, Limit: 10}},
+		{"last page", 25, 3, 10, PageInfo{CurrentPage: 3, TotalPages: 3, HasNext: false, HasPrev: true, Offset: 20, Limit: 10}},
+		{"after the last page", 25, 9, 10, PageInfo{CurrentPage: 3, TotalPages: 3, HasNext: false, HasPrev: true, Offset: 20, Limit: 10}},
+		{"before the first page", 25, -2, 10, PageInfo{CurrentPage: 1, TotalPages: 3, HasNext: true, HasPrev: false, Offset: 0, Limit: 10}},
+		{"single page", 7, 1, 10, PageInfo{CurrentPage: 1, TotalPages: 1, Limit: 10}},
+		{"exactly full pages", 20, 2, 10, PageInfo{CurrentPage: 2, TotalPages: 2, HasPrev: true, Offset: 10, Limit: 10}},
+		{"empty list", 0, 3, 10, PageInfo{CurrentPage: 1, TotalPages: 1, Limit: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Paginate(tt.total, tt.page, tt.perPage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Paginate(%d, %d, %d) = %+v, want %+v", tt.total, tt.page, tt.perPage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateInvalidPerPage(t *testing.T) {
+	for _, perPage := range []int{0, -1} {
+		if _, err := Paginate(10, 1, perPage); !errors.Is(err, ErrInvalidPerPage) {
+			t.Errorf("perPage %d: err = %v, want ErrInvalidPerPage", perPage, err)
+		}
+	}
+}
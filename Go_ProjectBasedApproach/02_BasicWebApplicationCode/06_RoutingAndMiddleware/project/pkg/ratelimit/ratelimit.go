@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket per key (eg: per client IP). Every bucket holds up to burst tokens and gets rate tokens
+// per second back, a request takes one token. Buckets nobody used for idleTimeout are dropped so the map can't grow forever.
+type Limiter struct {
+	rate        float64
+	burst       float64
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// now is time.Now, it is a field so the refill can be tried without sleeping
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter allowing rate requests per second per key, with bursts of up to burst requests
+func New(rate float64, burst int, idleTimeout time.Duration) *Limiter {
+	return &Limiter{
+		rate:        rate,
+		burst:       float64(burst),
+		idleTimeout: idleTimeout,
+		buckets:     map[string]*bucket{},
+		lastSweep:   time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key. When it is empty, ok is false and retryAfter is how long until
+// the next token is there.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// refill for the time since the last request, never above the burst
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	missing := 1 - b.tokens
+	return false, time.Duration(missing / l.rate * float64(time.Second))
+}
+
+// sweep drops the idle buckets, at most once per idleTimeout so it doesn't cost anything on most requests
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
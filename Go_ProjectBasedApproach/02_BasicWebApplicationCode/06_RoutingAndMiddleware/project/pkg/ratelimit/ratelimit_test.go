@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a fake time.Now, the tests move it forward instead of sleeping
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time      { return c.t }
+func (c *clock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+// limiter returns a limiter running on a fake clock
+func limiter(rate float64, burst int) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(rate, burst, time.Minute)
+	l.now = c.now
+	l.lastSweep = c.t
+	return l, c
+}
+
+func TestAllowBurst(t *testing.T) {
+	l, _ := limiter(1, 3)
+
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d of the burst was refused", i)
+		}
+	}
+	ok, retryAfter := l.Allow("10.0.0.1")
+	if ok {
+		t.Fatal("the request over the burst was allowed")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("retryAfter = %v, want 1s for one token at 1/s", retryAfter)
+	}
+
+	// every client has its own bucket
+	if ok, _ := l.Allow("10.0.0.2"); !ok {
+		t.Error("another client was refused")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l, c := limiter(2, 1)
+	l.Allow("10.0.0.1")
+	if ok, _ := l.Allow("10.0.0.1"); ok {
+		t.Fatal("the empty bucket allowed a request")
+	}
+
+	// at 2/s half a second gives the token back
+	c.add(500 * time.Millisecond)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("the bucket did not refill")
+	}
+
+	// a long pause refills up to the burst, not above it
+	c.add(time.Hour)
+	l.Allow("10.0.0.1")
+	if ok, _ := l.Allow("10.0.0.1"); ok {
+		t.Error("the bucket filled above the burst")
+	}
+}
+
+func TestSweepIdleBuckets(t *testing.T) {
+	l, c := limiter(1, 1)
+	l.Allow("10.0.0.1")
+	c.add(30 * time.Second)
+	l.Allow("10.0.0.2")
+
+	c.add(40 * time.Second)
+	l.Allow("10.0.0.3")
+
+	// .1 was idle for over a minute, .2 only 40s
+	if _, found := l.buckets["10.0.0.1"]; found {
+		t.Error("the idle bucket was kept")
+	}
+	if _, found := l.buckets["10.0.0.2"]; !found {
+		t.Error("a recently used bucket was dropped")
+	}
+}
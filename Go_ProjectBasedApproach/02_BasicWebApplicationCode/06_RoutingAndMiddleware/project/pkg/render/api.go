@@ -0,0 +1,104 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxJSONBodyBytes is the biggest request body ReadJSON accepts: 1MB
+const maxJSONBodyBytes = 1 << 20
+
+// bodyTooLargeError is returned by ReadJSON for a body over the limit, ReadJSONStatus turns it into a 413
+type bodyTooLargeError struct {
+	err *http.MaxBytesError
+}
+
+func (e *bodyTooLargeError) Error() string {
+	return fmt.Sprintf("body must not be larger than %d bytes", e.err.Limit)
+}
+
+func (e *bodyTooLargeError) Unwrap() error {
+	return e.err
+}
+
+// ReadJSONStatus is the status to answer a ReadJSON error with: 413 for a body that is too big, 400 for everything else
+func ReadJSONStatus(err error) int {
+	var tooLarge *bodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// ReadJSON decodes the JSON body of the request into dst. The body may be at most 1MB, may only have the fields
+// of dst and must hold exactly one JSON value. The returned errors are meant for the api client, eg:
+//
+//	err := render.ReadJSON(w, r, &reservation)
+//	if err != nil {
+//		render.RenderJSONError(w, render.ReadJSONStatus(err), err.Error())
+//		return
+//	}
+func ReadJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var typeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body has badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body has badly-formed JSON")
+		case errors.As(err, &typeError):
+			if typeError.Field != "" {
+				return fmt.Errorf("body has the wrong JSON type for the field %q", typeError.Field)
+			}
+			return fmt.Errorf("body has the wrong JSON type (at character %d)", typeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			// the json package has no error type for this one, so the field name is cut out of the message
+			field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body has the unknown field %s", field)
+		case errors.As(err, &maxBytesError):
+			return &bodyTooLargeError{err: maxBytesError}
+		default:
+			return err
+		}
+	}
+
+	// anything after the first value (eg: {"a":1}{"b":2}) is an error too
+	err = dec.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+	return nil
+}
+
+// WriteJSON writes data as JSON with the given status, the headers (may be nil) are added to the response first
+func WriteJSON(w http.ResponseWriter, status int, data interface{}, headers http.Header) error {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// guest is what the ReadJSON tests decode into
+type guest struct {
+	Name   string `json:"name"`
+	Guests int    `json:"guests"`
+}
+
+func TestReadJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/guests", strings.NewReader(`{"name":"Rahul","guests":2}`))
+	var dst guest
+	err := ReadJSON(httptest.NewRecorder(), req, &dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != (guest{Name: "Rahul", Guests: 2}) {
+		t.Errorf("got %+v", dst)
+	}
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+		status  int
+	}{
+		{"badly formed", `{"name":"Rahul",}`, "body has badly-formed JSON (at character 17)", http.StatusBadRequest},
+		{"cut off", `{"name":"Rahul"`, "body has badly-formed JSON", http.StatusBadRequest},
+		{"wrong type for a field", `{"guests":"two"}`, `body has the wrong JSON type for the field "guests"`, http.StatusBadRequest},
+		{"wrong type", `["Rahul"]`, "body has the wrong JSON type (at character 1)", http.StatusBadRequest},
+		{"empty", ``, "body must not be empty", http.StatusBadRequest},
+		{"unknown field", `{"name":"Rahul","admin":true}`, `body has the unknown field "admin"`, http.StatusBadRequest},
+		{"two values", `{"name":"Rahul"}{"name":"Rai"}`, "body must only contain a single JSON value", http.StatusBadRequest},
+		{"too large", `{"name":"` + strings.Repeat("a", maxJSONBodyBytes) + `"}`, "body must not be larger than 1048576 bytes", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/guests", strings.NewReader(tt.body))
+			var dst guest
+			err := ReadJSON(httptest.NewRecorder(), req, &dst)
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			if err.Error() != tt.message {
+				t.Errorf("error = %q, want %q", err, tt.message)
+			}
+			if got := ReadJSONStatus(err); got != tt.status {
+				t.Errorf("ReadJSONStatus = %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	rr := httptest.NewRecorder()
+	headers := http.Header{"Location": {"/api/guests/1"}}
+	err := WriteJSON(rr, http.StatusCreated, guest{Name: "Rahul", Guests: 2}, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rr.Header().Get("Location"); got != "/api/guests/1" {
+		t.Errorf("Location = %q", got)
+	}
+	if got := rr.Body.String(); got != `{"name":"Rahul","guests":2}` {
+		t.Errorf("body = %s", got)
+	}
+}
+
+func TestWriteJSONEncodeError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := WriteJSON(rr, http.StatusOK, make(chan int), nil)
+	if err == nil {
+		t.Fatal("a channel can't be encoded, want an error")
+	}
+	// nothing was written, so the caller can still answer with an error of its own
+	if rr.Body.Len() != 0 || len(rr.Header()) != 0 {
+		t.Errorf("got %v %q, want nothing written", rr.Header(), rr.Body)
+	}
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestAddDefaultData(t *testing.T) {
+	a := useTemplates(t, t.TempDir())
+	a.Session = scs.New()
+
+	// the previous request left messages in the session, the CSRF middleware put the token in the context
+	ctx, err := a.Session.Load(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Session.Put(ctx, "flash", "Saved")
+	a.Session.Put(ctx, "warning", "Almost full")
+	a.Session.Put(ctx, "error", "Try again")
+	ctx = csrf.NewContext(ctx, "the-token")
+	req := httptest.NewRequest(http.MethodGet, "/home", nil).WithContext(ctx)
+
+	td := AddDefaultData(&models.TemplateData{StringMap: map[string]string{"title": "Home"}}, req)
+
+	if td.StringMap["title"] != "Home" {
+		t.Errorf("the handler's StringMap was lost: %v", td.StringMap)
+	}
+	if td.CSRFToken != "the-token" || td.Flash != "Saved" || td.Warning != "Almost full" || td.Error != "Try again" {
+		t.Errorf("defaults = token %q flash %q warning %q error %q", td.CSRFToken, td.Flash, td.Warning, td.Error)
+	}
+	if td.CurrentYear != time.Now().Year() {
+		t.Errorf("CurrentYear = %d", td.CurrentYear)
+	}
+
+	// the messages are shown once, the next page doesn't get them again
+	td = AddDefaultData(nil, req)
+	if td.Flash != "" || td.Warning != "" || td.Error != "" {
+		t.Errorf("the messages were shown twice: %+v", td)
+	}
+}
+
+func TestAddDefaultDataWithoutSession(t *testing.T) {
+	// eg: the error page of the recover middleware, the session middleware never ran
+	a := useTemplates(t, t.TempDir())
+	a.Session = scs.New()
+
+	td := AddDefaultData(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+	if td == nil || td.Flash != "" || td.CurrentYear == 0 {
+		t.Errorf("got %+v, want the defaults without the session ones", td)
+	}
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+	"github.com/rahulrai17/porject/templates"
+)
+
+// embedded is a fixture of one page and one layout, compiled into the test binary
+//
+//go:embed testdata/embedded
+var embedded embed.FS
+
+func TestCreateTemplateCacheFS(t *testing.T) {
+	fsys, err := fs.Sub(embedded, "testdata/embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := useTemplates(t, writeTemplates(t, map[string]string{}))
+	a.TemplateFS = fsys
+
+	cache, err := CreateTemplateCacheFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(cache); len(got) != 1 || got[0] != "home.page.tmpl" {
+		t.Fatalf("cache has %v, want only the page", got)
+	}
+
+	// with TemplateFS set the pages come from it, the (empty) dir on disk isn't used
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/home", nil), "home.page.tmpl", &models.TemplateData{})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<html><body><h1>Embedded home</h1></body></html>") {
+		t.Errorf("got %d %q, want the embedded page in its layout", rr.Code, rr.Body)
+	}
+}
+
+func TestEmbeddedTemplatesMatchDisk(t *testing.T) {
+	// the binary ships templates.FS, it has to hold the same pages as the folder
+	useTemplates(t, "../../templates")
+	fromDisk, err := CreateTemplateCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFS, err := CreateTemplateCacheFS(templates.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages, _ := filepath.Glob("../../templates/*.page.tmpl")
+	if len(pages) == 0 {
+		t.Fatal("no pages on disk")
+	}
+	for _, page := range pages {
+		name := filepath.Base(page)
+		if fromDisk[name] == nil || fromFS[name] == nil {
+			t.Errorf("%s: on disk %v, embedded %v", name, fromDisk[name] != nil, fromFS[name] != nil)
+		}
+	}
+	if len(fromFS) != len(fromDisk) {
+		t.Errorf("embedded %v, on disk %v", keys(fromFS), keys(fromDisk))
+	}
+}
+
+func TestConditionalGetEmbedded(t *testing.T) {
+	fsys, err := fs.Sub(embedded, "testdata/embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := useTemplates(t, writeTemplates(t, map[string]string{}))
+	a.TemplateFS = fsys
+	a.ConditionalPages = []string{"home.page.tmpl"}
+	// production renders from the cache built at startup, like the deployed binary
+	a.InProduction = true
+	if a.TemplateCache, err = CreateTemplateCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	// embedded files have no modification time, the page gets the build time of the binary
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/home", nil), "home.page.tmpl", &models.TemplateData{})
+	lastModified := rr.Header().Get("Last-Modified")
+	want := embedModTime.UTC().Truncate(time.Second).Format(http.TimeFormat)
+	if rr.Code != http.StatusOK || lastModified != want {
+		t.Fatalf("got %d with Last-Modified %q, want 200 with %q", rr.Code, lastModified, want)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rr = render(t, req, "home.page.tmpl", &models.TemplateData{})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("got %d with %d bytes, want an empty 304", rr.Code, rr.Body.Len())
+	}
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// htmxErrorTarget is the element of base.layout.tmpl that htmx error fragments are swapped into
+const htmxErrorTarget = "#errors"
+
+// IsAPIRequest reports if the request is for the JSON api rather than for a page
+func IsAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// isHTMXRequest reports if htmx made the request, it only wants a piece of a page back
+func isHTMXRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// RenderError is the one place an error response is written, so every error (a handler's, a panic, a timeout, a broken
+// template) looks the same to the client: api requests get the JSON error envelope, htmx requests a small fragment
+// and everybody else error.page.tmpl with message shown above it. message is shown to the user, so it must not
+// contain internals like the error itself.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if IsAPIRequest(r) {
+		RenderJSONError(w, status, message)
+		return
+	}
+	if isHTMXRequest(r) {
+		writeHTMXError(w, status, message)
+		return
+	}
+	RenderTemplateStatus(w, r, "error.page.tmpl", &models.TemplateData{Error: message}, status)
+}
+
+// writeHTMXError sends the message as a fragment. htmx would swap a whole error page into whatever small element
+// made the request, so HX-Retarget sends the fragment to the page wide error container instead.
+func writeHTMXError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("HX-Retarget", htmxErrorTarget)
+	w.Header().Set("HX-Reswap", "innerHTML")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<div class="error" role="alert">%s</div>`, html.EscapeString(message))
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderError(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": `{{define "base"}}<html><body><div id="errors"></div>{{with .Error}}<p class="alert">{{.}}</p>{{end}}{{block "content" .}}{{end}}</body></html>{{end}}`,
+		"error.page.tmpl":  `{{template "base" .}}{{define "content"}}<h1>Oops!</h1>{{end}}`,
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		headers     map[string]string
+		contentType string
+		retarget    string
+		body        []string
+	}{
+		{
+			name:        "page",
+			path:        "/reservations",
+			contentType: "text/html; charset=utf-8",
+			body:        []string{"<html>", "<h1>Oops!</h1>", `<p class="alert">Something broke &lt;here&gt;</p>`},
+		},
+		{
+			name:        "htmx",
+			path:        "/reservations",
+			headers:     map[string]string{"HX-Request": "true"},
+			contentType: "text/html; charset=utf-8",
+			retarget:    "#errors",
+			body:        []string{`<div class="error" role="alert">Something broke &lt;here&gt;</div>`},
+		},
+		{
+			name:        "api",
+			path:        "/api/reservations",
+			contentType: "application/json",
+			body:        []string{`{"error":{"status":500,"message":"Something broke \u003chere\u003e"}}`},
+		},
+		{
+			name:        "accepts json",
+			path:        "/reservations",
+			headers:     map[string]string{"Accept": "application/json"},
+			contentType: "application/json",
+			body:        []string{`"status":500`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			RenderError(rr, req, http.StatusInternalServerError, "Something broke <here>")
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rr.Header().Get("HX-Retarget"); got != tt.retarget {
+				t.Errorf("HX-Retarget = %q, want %q", got, tt.retarget)
+			}
+			for _, want := range tt.body {
+				if !strings.Contains(rr.Body.String(), want) {
+					t.Errorf("body %q does not contain %q", rr.Body, want)
+				}
+			}
+			// the fragment is swapped into the page, it must not bring a whole document with it
+			if tt.retarget != "" && strings.Contains(rr.Body.String(), "<html>") {
+				t.Errorf("the htmx fragment contains a full page: %q", rr.Body)
+			}
+		})
+	}
+}
+
+func TestFallbackErrorForHTMX(t *testing.T) {
+	// the page itself is broken, so even the htmx request can't be answered with it
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"broken.page.tmpl": `{{template "nothere" .}}`,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/broken", nil)
+	req.Header.Set("HX-Request", "true")
+	rr := httptest.NewRecorder()
+	RenderTemplate(rr, req, "broken.page.tmpl", nil)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+	if rr.Header().Get("HX-Retarget") != "#errors" || strings.Contains(rr.Body.String(), "<html") {
+		t.Errorf("want the retargeted fragment, got %v %q", rr.Header(), rr.Body)
+	}
+}
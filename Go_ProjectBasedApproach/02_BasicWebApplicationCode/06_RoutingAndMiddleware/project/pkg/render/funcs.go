@@ -0,0 +1,131 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// functions are the helper funcs every template can call, they are attached when the cache is built
+var functions = template.FuncMap{
+	"csrfField":   csrfField,
+	"plural":      plural,
+	"pluralf":     pluralf,
+	"flag":        flag,
+	"hasRole":     hasRole,
+	"currentUser": currentUser,
+	"errorFor":    errorFor,
+	"hasError":    hasError,
+	"backLink":    backLink,
+	"formatDate":  formatDate,
+	"add":         add,
+	"asset":       asset,
+}
+
+// csrfField returns the hidden input carrying the CSRF token, so a form only needs {{csrfField .}} and the field name
+// is always the one the CSRF check expects
+func csrfField(td *models.TemplateData) template.HTML {
+	if td == nil {
+		return ""
+	}
+	return template.HTML(`<input type="hidden" name="` + csrf.FieldName + `" value="` + template.HTMLEscapeString(td.CSRFToken) + `">`)
+}
+
+// plural returns singular when n is 1 and plural otherwise (zero is plural too: "0 reservations"),
+// eg: {{.Count}} {{plural .Count "reservation" "reservations"}}
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// pluralf is plural with the count put into the chosen form, eg: {{pluralf .Count "%d reservation" "%d reservations"}}
+func pluralf(n int, singular, pluralForm string) string {
+	return fmt.Sprintf(plural(n, singular, pluralForm), n)
+}
+
+// flag reports if the feature flag is on for the current request, eg: {{if flag . "new_nav"}}...{{end}}
+func flag(td *models.TemplateData, name string) bool {
+	return td != nil && td.FeatureFlag != nil && td.FeatureFlag(name)
+}
+
+// currentUser returns the logged in user or nil, eg: {{with currentUser .}}Hi {{.Name}}{{end}}
+func currentUser(td *models.TemplateData) *models.User {
+	if td == nil {
+		return nil
+	}
+	return td.User
+}
+
+// hasRole reports if the logged in user has the role, so admin only parts can be guarded with {{if hasRole . "admin"}}.
+// It is false when nobody is logged in.
+func hasRole(td *models.TemplateData, role string) bool {
+	return td != nil && td.User.HasRole(role)
+}
+
+// errorFor returns the first validation error of the field, or "" when it has none,
+// eg: <div class="invalid-feedback">{{errorFor . "email"}}</div>
+func errorFor(td *models.TemplateData, field string) string {
+	if td == nil || td.Form == nil {
+		return ""
+	}
+	return td.Form.Errors.Get(field)
+}
+
+// hasError reports if the field has a validation error, eg: <input class="{{if hasError . "email"}}is-invalid{{end}}">
+func hasError(td *models.TemplateData, field string) bool {
+	return errorFor(td, field) != ""
+}
+
+// backLink returns the page the user came from when it is on this site, otherwise fallback,
+// eg: <a href="{{backLink . "/reservations"}}">Back</a>
+func backLink(td *models.TemplateData, fallback string) string {
+	if td == nil || td.Referer == "" {
+		return fallback
+	}
+	return td.Referer
+}
+
+// formatDate formats the time with a Go layout, eg: {{formatDate .CreatedAt "2006-01-02"}}. The zero time gives "".
+func formatDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// add returns a + b, handy for numbering from 1 in a range: {{range $i, $r := .List}}{{add $i 1}}{{end}}
+func add(a, b int) int {
+	return a + b
+}
+
+// asset returns the URL of a static file with its modification time added, eg: {{asset "/static/css/style.css"}} gives
+// "/static/css/style.css?v=6530e1f2". A deploy changes the mtime and so the URL, browsers then fetch the new file instead
+// of using the cached one. Files that can't be found are returned unchanged.
+func asset(urlPath string) string {
+	name, ok := strings.CutPrefix(urlPath, "/static/")
+	if !ok || app == nil {
+		return urlPath
+	}
+
+	var info fs.FileInfo
+	var err error
+	if app.StaticFS != nil {
+		info, err = fs.Stat(app.StaticFS, name)
+	} else {
+		info, err = os.Stat(filepath.Join(app.StaticDir, filepath.FromSlash(name)))
+	}
+	if err != nil {
+		return urlPath
+	}
+	return urlPath + "?v=" + strconv.FormatInt(info.ModTime().Unix(), 16)
+}
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/auth"
+	"github.com/rahulrai17/porject/pkg/flags"
+	"github.com/rahulrai17/porject/pkg/forms"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestCSRFField(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"form.page.tmpl":   `{{template "base" .}}{{define "content"}}<form method="post">{{csrfField .}}</form>{{end}}`,
+	}))
+
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/form", nil), "form.page.tmpl", &models.TemplateData{CSRFToken: `tok"en<1>`})
+	want := `<form method="post"><input type="hidden" name="csrf_token" value="tok&#34;en&lt;1&gt;"></form>`
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("body = %q, want %q", rr.Body, want)
+	}
+
+	if got := csrfField(nil); got != "" {
+		t.Errorf("csrfField(nil) = %q, want nothing", got)
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		n       int
+		plural  string
+		pluralf string
+	}{
+		{0, "reservations", "0 reservations"},
+		{1, "reservation", "1 reservation"},
+		{2, "reservations", "2 reservations"},
+	}
+	for _, tt := range tests {
+		if got := plural(tt.n, "reservation", "reservations"); got != tt.plural {
+			t.Errorf("plural(%d) = %q, want %q", tt.n, got, tt.plural)
+		}
+		if got := pluralf(tt.n, "%d reservation", "%d reservations"); got != tt.pluralf {
+			t.Errorf("pluralf(%d) = %q, want %q", tt.n, got, tt.pluralf)
+		}
+	}
+}
+
+func TestPluralInTemplate(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"count.page.tmpl":  `{{template "base" .}}{{define "content"}}{{$n := index .IntMap "n"}}{{$n}} {{plural $n "guest" "guests"}}, {{pluralf $n "%d night" "%d nights"}}{{end}}`,
+	}))
+
+	for n, want := range map[int]string{0: "0 guests, 0 nights", 1: "1 guest, 1 night", 2: "2 guests, 2 nights"} {
+		rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), "count.page.tmpl", &models.TemplateData{IntMap: map[string]int{"n": n}})
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("n=%d: body = %q, want %q", n, rr.Body, want)
+		}
+	}
+}
+
+func TestFlagFunc(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"nav.page.tmpl":    `{{template "base" .}}{{define "content"}}{{if flag . "new_nav"}}new{{else}}old{{end}}{{end}}`,
+	}))
+
+	tests := []struct {
+		name    string
+		rollout map[string]int
+		want    string
+	}{
+		{"enabled", map[string]int{"new_nav": 100}, "<body>new</body>"},
+		{"disabled", map[string]int{"new_nav": 0}, "<body>old</body>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(flags.NewContext(req.Context(), flags.NewStatic(tt.rollout)))
+			rr := render(t, req, "nav.page.tmpl", &models.TemplateData{})
+			if !strings.Contains(rr.Body.String(), tt.want) {
+				t.Errorf("body = %q, want %q", rr.Body, tt.want)
+			}
+		})
+	}
+
+	// without the flags middleware every flag is off
+	if rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), "nav.page.tmpl", nil); !strings.Contains(rr.Body.String(), "old") {
+		t.Errorf("body = %q, want the flag off", rr.Body)
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"menu.page.tmpl":   `{{template "base" .}}{{define "content"}}<a>Home</a>{{if hasRole . "admin"}}<a>Admin</a>{{end}}{{end}}`,
+	}))
+
+	tests := []struct {
+		name  string
+		user  *models.User
+		shown bool
+	}{
+		{"admin", &models.User{ID: 1, Roles: []string{"staff", "admin"}}, true},
+		{"regular user", &models.User{ID: 2, Roles: []string{"staff"}}, false},
+		{"no user", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.user != nil {
+				// the user comes from the auth middleware like in the app
+				req = req.WithContext(auth.NewContext(req.Context(), tt.user))
+			}
+			rr := render(t, req, "menu.page.tmpl", &models.TemplateData{})
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d", rr.Code)
+			}
+			if got := strings.Contains(rr.Body.String(), "<a>Admin</a>"); got != tt.shown {
+				t.Errorf("admin link shown = %v, want %v: %q", got, tt.shown, rr.Body)
+			}
+		})
+	}
+}
+
+func TestErrorFor(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"form.page.tmpl": `{{template "base" .}}{{define "content"}}` +
+			`<input name="name" class="{{if hasError . "name"}}is-invalid{{end}}"><div>{{errorFor . "name"}}</div>` +
+			`<input name="email" class="{{if hasError . "email"}}is-invalid{{end}}"><div>{{errorFor . "email"}}</div>` +
+			`{{end}}`,
+	}))
+
+	form := forms.New(url.Values{"name": {"Rahul"}, "email": {"nope"}})
+	form.IsEmail("email")
+	form.Errors.Add("email", "a second message")
+
+	rr := render(t, httptest.NewRequest(http.MethodPost, "/", nil), "form.page.tmpl", &models.TemplateData{Form: form})
+	want := `<input name="name" class=""><div></div>` +
+		`<input name="email" class="is-invalid"><div>Invalid email address</div>`
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("body = %q, want %q", rr.Body, want)
+	}
+
+	// a page without a form shows no errors
+	rr = render(t, httptest.NewRequest(http.MethodGet, "/", nil), "form.page.tmpl", nil)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "is-invalid") {
+		t.Errorf("got %d %q, want the form without errors", rr.Code, rr.Body)
+	}
+}
+
+func TestBackLink(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"step.page.tmpl":   `{{template "base" .}}{{define "content"}}<a href="{{backLink . "/reservations"}}">Back</a>{{end}}`,
+	}))
+
+	tests := []struct {
+		name    string
+		referer string
+		want    string
+	}{
+		{"same origin", "http://example.com/reservations?page=2", "/reservations?page=2"},
+		{"external", "https://evil.com/phish", "/reservations"},
+		{"protocol relative path", "http://example.com//evil.com/x", "/reservations"},
+		{"javascript", "javascript:alert(1)", "/reservations"},
+		{"no referer", "", "/reservations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/step/2", nil)
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			rr := render(t, req, "step.page.tmpl", nil)
+			want := `<a href="` + template.HTMLEscapeString(tt.want) + `">Back</a>`
+			if !strings.Contains(rr.Body.String(), want) {
+				t.Errorf("body = %q, want %q", rr.Body, want)
+			}
+		})
+	}
+}
+
+func TestFormatDateAndAdd(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"list.page.tmpl": `{{template "base" .}}{{define "content"}}` +
+			`{{formatDate (index .Data "when") "2006-01-02"}}|{{formatDate (index .Data "never") "2006-01-02"}}|` +
+			`{{range $i, $name := index .Data "names"}}{{add $i 1}}.{{$name}} {{end}}{{end}}`,
+	}))
+
+	td := &models.TemplateData{Data: map[string]interface{}{
+		"when":  time.Date(2024, 3, 9, 15, 4, 0, 0, time.UTC),
+		"never": time.Time{},
+		"names": []string{"Ann", "Bob"},
+	}}
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), "list.page.tmpl", td)
+
+	// the zero time shows as nothing instead of 0001-01-01
+	want := "2024-03-09||1.Ann 2.Bob "
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("body = %q, want %q", rr.Body, want)
+	}
+}
+
+func TestAsset(t *testing.T) {
+	dir := t.TempDir()
+	css := filepath.Join(dir, "app.css")
+	if err := os.WriteFile(css, []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := useTemplates(t, writeTemplates(t, map[string]string{}))
+	a.StaticDir = dir
+
+	touch := func(mtime time.Time) string {
+		t.Helper()
+		if err := os.Chtimes(css, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return asset("/static/app.css")
+	}
+	first := touch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !strings.HasPrefix(first, "/static/app.css?v=") {
+		t.Fatalf("asset = %q, want the version added", first)
+	}
+	if again := asset("/static/app.css"); again != first {
+		t.Errorf("the same file got %q and %q", first, again)
+	}
+	// a deploy changes the mtime and so the URL, the browser fetches the new file
+	if second := touch(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)); second == first {
+		t.Errorf("the URL %q didn't change with the mtime", second)
+	}
+
+	// anything we can't version is left as it is
+	for _, path := range []string{"/static/missing.css", "/images/logo.png", "https://cdn.example.com/app.css"} {
+		if got := asset(path); got != path {
+			t.Errorf("asset(%q) = %q, want it unchanged", path, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestTemplateGlobs(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.gohtml":             `{{define "base"}}<main>{{block "content" .}}{{end}}</main>{{template "footer" .}}{{end}}`,
+		"home.page.gohtml":               `{{template "base" .}}{{define "content"}}home{{end}}`,
+		"partials/footer.partial.gohtml": `{{define "footer"}}<footer>f</footer>{{end}}`,
+		"old.page.tmpl":                  `{{template "base" .}}{{define "content"}}old{{end}}`,
+	}))
+	a.PageGlob = "*.page.gohtml"
+	a.LayoutGlob = "*.layout.gohtml"
+	a.PartialGlob = "partials/*.partial.gohtml"
+	a.DefaultLayout = "base.layout.gohtml"
+
+	cache, err := CreateTemplateCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache["old.page.tmpl"]; ok || len(cache) != 1 {
+		t.Errorf("cached %v, want only home.page.gohtml", keys(cache))
+	}
+	ts, ok := cache["home.page.gohtml"]
+	if !ok {
+		t.Fatalf("cached %v, want home.page.gohtml", keys(cache))
+	}
+	var buf bytes.Buffer
+	if err := ts.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<main>home</main><footer>f</footer>" {
+		t.Errorf("home.page.gohtml = %q, want it with its layout and partial", buf.String())
+	}
+}
+
+func TestValidateTemplateGlobs(t *testing.T) {
+	a := useTemplates(t, t.TempDir())
+	if err := ValidateTemplateGlobs(); err != nil {
+		t.Errorf("the default patterns are invalid: %v", err)
+	}
+
+	a.LayoutGlob = "*.layout.[gohtml"
+	err := ValidateTemplateGlobs()
+	if err == nil || !strings.Contains(err.Error(), "*.layout.[gohtml") {
+		t.Errorf("err = %v, want the bad pattern named", err)
+	}
+}
+
+// keys returns the names of the cached templates
+func keys(cache map[string]*template.Template) []string {
+	var names []string
+	for name := range cache {
+		names = append(names, name)
+	}
+	return names
+}
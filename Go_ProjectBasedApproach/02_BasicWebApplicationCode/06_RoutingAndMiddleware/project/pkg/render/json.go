@@ -0,0 +1,65 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// jsonList is the envelope of every paginated JSON list: {"data": [...], "meta": {...}}
+type jsonList struct {
+	Data interface{}       `json:"data"`
+	Meta models.Pagination `json:"meta"`
+}
+
+// RenderJSONList writes items and their pagination meta as JSON with the given status
+func RenderJSONList(w http.ResponseWriter, status int, items interface{}, meta models.Pagination) {
+	renderJSON(w, status, jsonList{Data: items, Meta: meta})
+}
+
+// jsonError is the envelope of every JSON error: {"error": {"status": 404, "message": "..."}}
+type jsonError struct {
+	Error jsonErrorBody `json:"error"`
+}
+
+type jsonErrorBody struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// RenderJSONError writes the JSON error envelope with the given status
+func RenderJSONError(w http.ResponseWriter, status int, message string) {
+	renderJSON(w, status, jsonError{Error: jsonErrorBody{Status: status, Message: message}})
+}
+
+// jsonSuccess is the envelope of JSON success responses: {"data": ..., "message": "..."}, the JSON version of a flash message
+type jsonSuccess struct {
+	Data    interface{} `json:"data"`
+	Message string      `json:"message"`
+}
+
+// RenderJSONSuccess writes data together with a human readable message with the given status
+func RenderJSONSuccess(w http.ResponseWriter, status int, data interface{}, message string) {
+	renderJSON(w, status, jsonSuccess{Data: data, Message: message})
+}
+
+// renderJSON writes the envelope with WriteJSON. A value that can't be encoded is a bug on our side and is answered
+// with a 500 (nothing was written yet), a failed write only means the client went away and is just logged.
+func renderJSON(w http.ResponseWriter, status int, envelope interface{}) {
+	err := WriteJSON(w, status, envelope, nil)
+	if err == nil {
+		return
+	}
+
+	var typeError *json.UnsupportedTypeError
+	var valueError *json.UnsupportedValueError
+	var marshalerError *json.MarshalerError
+	if errors.As(err, &typeError) || errors.As(err, &valueError) || errors.As(err, &marshalerError) {
+		errorLog().Error("could not encode the JSON response", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	errorLog().Error("could not write the JSON response", "error", err)
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestJSONEnvelopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(w http.ResponseWriter)
+		status int
+		body   string
+	}{
+		{
+			name: "list",
+			render: func(w http.ResponseWriter) {
+				RenderJSONList(w, http.StatusOK, []int{1, 2}, models.Pagination{Page: 1, PerPage: 2, Total: 3, TotalPages: 2})
+			},
+			status: http.StatusOK,
+			body:   `{"data":[1,2],"meta":{"page":1,"per_page":2,"total":3,"total_pages":2}}`,
+		},
+		{
+			name:   "error",
+			render: func(w http.ResponseWriter) { RenderJSONError(w, http.StatusNotFound, "reservation not found") },
+			status: http.StatusNotFound,
+			body:   `{"error":{"status":404,"message":"reservation not found"}}`,
+		},
+		{
+			name: "success",
+			render: func(w http.ResponseWriter) {
+				RenderJSONSuccess(w, http.StatusCreated, map[string]int{"id": 1}, "Reservation created")
+			},
+			status: http.StatusCreated,
+			body:   `{"data":{"id":1},"message":"Reservation created"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.render(rr)
+
+			if rr.Code != tt.status {
+				t.Errorf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if got := rr.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if rr.Body.String() != tt.body {
+				t.Errorf("body = %s, want %s", rr.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestRenderJSONEncodeError(t *testing.T) {
+	old := app
+	app = &config.AppConfig{ErrorLog: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	t.Cleanup(func() { app = old })
+
+	rr := httptest.NewRecorder()
+	RenderJSONSuccess(rr, http.StatusOK, func() {}, "")
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+}
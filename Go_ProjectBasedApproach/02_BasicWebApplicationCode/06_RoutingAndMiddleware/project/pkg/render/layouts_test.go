@@ -0,0 +1,56 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestLayoutFor(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{}))
+	a.PageLayouts = map[string]string{"dashboard.page.tmpl": "admin.layout.tmpl"}
+
+	if got := LayoutFor("dashboard.page.tmpl"); got != "admin.layout.tmpl" {
+		t.Errorf("LayoutFor(dashboard) = %q, want admin.layout.tmpl", got)
+	}
+	if got := LayoutFor("home.page.tmpl"); got != "base.layout.tmpl" {
+		t.Errorf("LayoutFor(home) = %q, want base.layout.tmpl", got)
+	}
+	a.DefaultLayout = "site.layout.tmpl"
+	if got := LayoutFor("home.page.tmpl"); got != "site.layout.tmpl" {
+		t.Errorf("LayoutFor(home) = %q, want the DefaultLayout", got)
+	}
+}
+
+func TestPagesWithDifferentLayouts(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl":    `{{define "base"}}<main class="site">{{block "content" .}}{{end}}</main>{{end}}`,
+		"admin.layout.tmpl":   `{{define "base"}}<main class="admin">{{block "content" .}}{{end}}</main>{{end}}`,
+		"home.page.tmpl":      `{{template "base" .}}{{define "content"}}<h1>Home</h1>{{end}}`,
+		"dashboard.page.tmpl": `{{template "base" .}}{{define "content"}}<h1>Dashboard</h1>{{end}}`,
+	}))
+	a.PageLayouts = map[string]string{"dashboard.page.tmpl": "admin.layout.tmpl"}
+
+	tests := []struct {
+		page string
+		want string
+	}{
+		{"home.page.tmpl", `<main class="site"><h1>Home</h1></main>`},
+		{"dashboard.page.tmpl", `<main class="admin"><h1>Dashboard</h1></main>`},
+	}
+	for _, tt := range tests {
+		rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), tt.page, &models.TemplateData{})
+		if !strings.Contains(rr.Body.String(), tt.want) {
+			t.Errorf("%s rendered %q, want %q", tt.page, rr.Body, tt.want)
+		}
+	}
+
+	// a layout that doesn't exist stops the cache from being built
+	a.PageLayouts["home.page.tmpl"] = "nope.layout.tmpl"
+	if _, err := CreateTemplateCache(); err == nil || !strings.Contains(err.Error(), `"nope.layout.tmpl"`) {
+		t.Errorf("err = %v, want the missing layout", err)
+	}
+}
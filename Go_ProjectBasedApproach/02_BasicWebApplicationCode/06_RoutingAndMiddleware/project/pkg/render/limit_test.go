@@ -0,0 +1,49 @@
+package render
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"rows.page.tmpl":   `{{template "base" .}}{{define "content"}}<table>{{range .Data.rows}}<tr><td>a row of the table</td></tr>{{end}}</table>{{end}}`,
+	}))
+	var logs bytes.Buffer
+	a.ErrorLog = slog.New(slog.NewTextHandler(&logs, nil))
+	a.MaxResponseBytes = 4 << 10
+
+	rows := func(n int) *models.TemplateData {
+		return &models.TemplateData{Data: map[string]interface{}{"rows": make([]int, n)}}
+	}
+
+	// a page under the cap is sent as usual
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/rows", nil), "rows.page.tmpl", rows(10))
+	if rr.Code != http.StatusOK || strings.Count(rr.Body.String(), "<tr>") != 10 {
+		t.Errorf("small page: got %d with %d rows", rr.Code, strings.Count(rr.Body.String(), "<tr>"))
+	}
+	if logs.Len() != 0 {
+		t.Errorf("the small page logged %q", logs.String())
+	}
+
+	// the loop runs away, the visitor gets the error page instead of megabytes of rows
+	rr = render(t, httptest.NewRequest(http.MethodGet, "/rows", nil), "rows.page.tmpl", rows(100000))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rr.Code)
+	}
+	if rr.Body.Len() > a.MaxResponseBytes || strings.Contains(rr.Body.String(), "<tr>") {
+		t.Errorf("sent %d bytes of the page, want only the error page", rr.Body.Len())
+	}
+	for _, want := range []string{"rows.page.tmpl", "larger than MaxResponseBytes", "4096 bytes"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q does not contain %q", logs.String(), want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+// whitespaceRun matches any run of whitespace, it is collapsed to a single space
+var whitespaceRun = regexp.MustCompile(`\s+`)
+
+// preservedTags are the elements whose content must be sent exactly as written
+var preservedTags = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+// minifyHTML collapses the whitespace the templates leave between tags and drops HTML comments.
+// It walks the document with the html tokenizer (instead of a regex over the whole page) so markup is never
+// corrupted, and text inside <pre>, <textarea>, <script> and <style> is written back untouched.
+func minifyHTML(in *bytes.Buffer) (*bytes.Buffer, error) {
+	out := new(bytes.Buffer)
+	out.Grow(in.Len())
+
+	z := html.NewTokenizer(in)
+	preserved := 0 // how many preserved elements we are inside
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return out, nil
+			}
+			return nil, z.Err()
+
+		case html.CommentToken:
+			// comments are only for the template authors
+
+		case html.TextToken:
+			if preserved > 0 {
+				out.Write(z.Raw())
+				continue
+			}
+			text := whitespaceRun.ReplaceAll(z.Raw(), []byte(" "))
+			// a dropped comment leaves two text tokens side by side, their spaces become one
+			if bytes.HasPrefix(text, []byte(" ")) && bytes.HasSuffix(out.Bytes(), []byte(" ")) {
+				text = text[1:]
+			}
+			out.Write(text)
+
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if preservedTags[string(name)] {
+				preserved++
+			}
+			out.Write(z.Raw())
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if preservedTags[string(name)] && preserved > 0 {
+				preserved--
+			}
+			out.Write(z.Raw())
+
+		default:
+			// doctype and self-closing tags
+			out.Write(z.Raw())
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	in := "<!DOCTYPE html>\n<html>\n  <body>\n    <!-- layout note -->\n    <p>Hello\n\n      world</p>\n" +
+		"    <pre>  keep\n    this  </pre>\n    <textarea>  and\n  this</textarea>\n" +
+		"    <script>if (a  <  b) {\n  go()\n}</script>\n  </body>\n</html>\n"
+
+	out, err := minifyHTML(bytes.NewBufferString(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<!DOCTYPE html> <html> <body> <p>Hello world</p> " +
+		"<pre>  keep\n    this  </pre> <textarea>  and\n  this</textarea> " +
+		"<script>if (a  <  b) {\n  go()\n}</script> </body> </html> "
+	if out.String() != want {
+		t.Errorf("got  %q\nwant %q", out, want)
+	}
+}
+
+func TestRenderTemplateMinify(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   "{{template \"base\" .}}{{define \"content\"}}\n\n  <h1>Home</h1>\n  <pre>a\n  b</pre>\n{{end}}",
+	}))
+
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), "home.page.tmpl", nil)
+	if !bytes.Contains(rr.Body.Bytes(), []byte("\n\n  <h1>")) {
+		t.Errorf("the page was minified with MinifyHTML off: %q", rr.Body)
+	}
+
+	a.MinifyHTML = true
+	rr = render(t, httptest.NewRequest(http.MethodGet, "/", nil), "home.page.tmpl", nil)
+	want := "<html><body> <h1>Home</h1> <pre>a\n  b</pre> </body></html>"
+	if rr.Body.String() != want {
+		t.Errorf("got  %q\nwant %q", rr.Body, want)
+	}
+}
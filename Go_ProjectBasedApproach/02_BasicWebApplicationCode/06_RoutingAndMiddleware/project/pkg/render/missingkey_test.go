@@ -0,0 +1,40 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestStrictMissingKeys(t *testing.T) {
+	files := map[string]string{
+		"base.layout.tmpl": testLayout,
+		"title.page.tmpl":  `{{template "base" .}}{{define "content"}}<h1>[{{.StringMap.title}}]</h1>{{end}}`,
+	}
+	td := func() *models.TemplateData {
+		return &models.TemplateData{StringMap: map[string]string{"other": "x"}}
+	}
+
+	// by default the missing key quietly renders (html/template prints it as nothing rather than "<no value>")
+	useTemplates(t, writeTemplates(t, files))
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), "title.page.tmpl", td())
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<h1>[]</h1>") {
+		t.Errorf("default mode: got %d %q, want the page", rr.Code, rr.Body)
+	}
+
+	a := useTemplates(t, writeTemplates(t, files))
+	a.StrictMissingKeys = true
+	rr = render(t, httptest.NewRequest(http.MethodGet, "/", nil), "title.page.tmpl", td())
+	if rr.Code != http.StatusInternalServerError || strings.Contains(rr.Body.String(), "<h1>[") {
+		t.Errorf("strict mode: got %d %q, want the 500 page", rr.Code, rr.Body)
+	}
+
+	// a key that is there still renders in strict mode
+	rr = render(t, httptest.NewRequest(http.MethodGet, "/", nil), "title.page.tmpl", &models.TemplateData{StringMap: map[string]string{"title": "Hi"}})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<h1>[Hi]</h1>") {
+		t.Errorf("strict mode: got %d %q, want the title", rr.Code, rr.Body)
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestOpenGraphTags(t *testing.T) {
+	// the real partial, inside a layout that only has the head
+	partial, err := os.ReadFile("../../templates/partials/opengraph.partial.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl":                `{{define "base"}}<head>{{template "opengraph" .}}</head>{{end}}`,
+		"page.page.tmpl":                  `{{template "base" .}}`,
+		"partials/opengraph.partial.tmpl": string(partial),
+	}))
+	a.BaseURL = "https://example.com/"
+	a.DefaultOpenGraph = models.OpenGraph{
+		Title:       "Fort Smythe",
+		Description: "Book your stay",
+		Image:       "https://example.com/static/images/preview.png",
+	}
+
+	tests := []struct {
+		name string
+		og   *models.OpenGraph
+		want []string
+	}{
+		{
+			name: "handler values",
+			og:   &models.OpenGraph{Title: "General's Quarters", Description: "Our biggest room", Image: "https://example.com/gq.png", URL: "https://example.com/rooms/gq"},
+			want: []string{
+				`<meta property="og:title" content="General&#39;s Quarters" />`,
+				`<meta property="og:description" content="Our biggest room" />`,
+				`<meta property="og:image" content="https://example.com/gq.png" />`,
+				`<meta property="og:url" content="https://example.com/rooms/gq" />`,
+			},
+		},
+		{
+			name: "defaults",
+			want: []string{
+				`<meta property="og:title" content="Fort Smythe" />`,
+				`<meta property="og:description" content="Book your stay" />`,
+				`<meta property="og:image" content="https://example.com/static/images/preview.png" />`,
+				`<meta property="og:url" content="https://example.com/about" />`,
+			},
+		},
+		{
+			name: "some fields set",
+			og:   &models.OpenGraph{Title: "Contact us"},
+			want: []string{
+				`<meta property="og:title" content="Contact us" />`,
+				`<meta property="og:description" content="Book your stay" />`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := render(t, httptest.NewRequest(http.MethodGet, "/about", nil), "page.page.tmpl", &models.TemplateData{OpenGraph: tt.og})
+			for _, want := range tt.want {
+				if !strings.Contains(rr.Body.String(), want) {
+					t.Errorf("head %q does not contain %s", rr.Body, want)
+				}
+			}
+		})
+	}
+}
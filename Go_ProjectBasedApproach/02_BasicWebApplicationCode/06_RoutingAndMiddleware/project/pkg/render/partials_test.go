@@ -0,0 +1,44 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestPartials(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl":           `{{define "base"}}<html><body>{{template "nav" .}}{{block "content" .}}{{end}}</body></html>{{end}}`,
+		"home.page.tmpl":             `{{template "base" .}}{{define "content"}}<h1>Home</h1>{{template "card" .}}{{end}}`,
+		"about.page.tmpl":            `{{template "base" .}}{{define "content"}}<h1>About</h1>{{template "card" .}}{{end}}`,
+		"partials/nav.partial.tmpl":  `{{define "nav"}}<nav>the menu</nav>{{end}}`,
+		"partials/card.partial.tmpl": `{{define "card"}}<div class="card">{{index .StringMap "title"}}</div>{{end}}`,
+	}))
+
+	// every page gets every partial, from the layout and from the page itself
+	for _, page := range []string{"home.page.tmpl", "about.page.tmpl"} {
+		td := &models.TemplateData{StringMap: map[string]string{"title": page}}
+		rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), page, td)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", page, rr.Code, rr.Body)
+		}
+		for _, want := range []string{"<nav>the menu</nav>", `<div class="card">` + page + `</div>`} {
+			if !strings.Contains(rr.Body.String(), want) {
+				t.Errorf("%s: body %q does not contain %q", page, rr.Body, want)
+			}
+		}
+	}
+}
+
+func TestNavPartial(t *testing.T) {
+	// the real templates, the base layout brings the nav to every page
+	useTemplates(t, "../../templates")
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/about", nil), "about.page.tmpl", &models.TemplateData{})
+
+	if !strings.Contains(rr.Body.String(), `<a href="/reservations">Reservations</a>`) {
+		t.Errorf("the about page has no nav: %q", rr.Body)
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInProductionUsesCache(t *testing.T) {
+	page := `{{template "base" .}}{{define "content"}}%s{{end}}`
+	dir := writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   strings.Replace(page, "%s", "version one", 1),
+	})
+	a := useTemplates(t, dir)
+	var logs bytes.Buffer
+	a.InfoLog = slog.New(slog.NewTextHandler(&logs, nil))
+
+	cache, err := CreateTemplateCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.TemplateCache = cache
+
+	// the template changes on disk after the cache was built
+	err = os.WriteFile(filepath.Join(dir, "home.page.tmpl"), []byte(strings.Replace(page, "%s", "version two", 1)), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := func() string {
+		return render(t, httptest.NewRequest(http.MethodGet, "/", nil), "home.page.tmpl", nil).Body.String()
+	}
+
+	a.InProduction = true
+	if got := home(); !strings.Contains(got, "version one") {
+		t.Errorf("production rendered %q, want the cached version one", got)
+	}
+
+	a.InProduction = false
+	if got := home(); !strings.Contains(got, "version two") {
+		t.Errorf("development rendered %q, want the rebuilt version two", got)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("warned without UseCache: %q", logs.String())
+	}
+
+	// UseCache can't turn the rebuild off in development, it only causes a warning
+	a.UseCache = true
+	if got := home(); !strings.Contains(got, "version two") {
+		t.Errorf("development with UseCache rendered %q, want the rebuilt version two", got)
+	}
+	if !strings.Contains(logs.String(), "UseCache is ignored in development") {
+		t.Errorf("no warning about UseCache: %q", logs.String())
+	}
+}
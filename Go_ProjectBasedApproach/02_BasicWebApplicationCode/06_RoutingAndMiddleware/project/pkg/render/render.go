@@ -2,12 +2,29 @@ package render
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template/parse"
+	"time"
 
+	"github.com/rahulrai17/porject/pkg/auth"
 	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"github.com/rahulrai17/porject/pkg/flags"
+	"github.com/rahulrai17/porject/pkg/logging"
 	"github.com/rahulrai17/porject/pkg/models"
 )
 
@@ -19,82 +36,702 @@ func NewTemplates(a *config.AppConfig){
 	app = a
 }
 
-func RenderTemplate(w http.ResponseWriter, tmpl string, td *models.TemplateData){
+// infoLog and errorLog are the loggers of the AppConfig, the default slog logger is used until they are set
+func infoLog() *slog.Logger {
+	if app == nil {
+		return slog.Default()
+	}
+	return logging.Or(app.InfoLog)
+}
+
+func errorLog() *slog.Logger {
+	if app == nil {
+		return slog.Default()
+	}
+	return logging.Or(app.ErrorLog)
+}
+
+// templateModTimes is the cache metadata: for every page it holds the newest modification time of the files it was built from
+var templateModTimes = map[string]time.Time{}
+
+// templateUsesToken is cache metadata too: the pages whose templates render the CSRF token (see usesCSRFToken)
+var templateUsesToken = map[string]bool{}
+
+// modTimesMu protects templateModTimes and templateUsesToken since the cache can be rebuilt while other requests are reading it
+var modTimesMu sync.RWMutex
+
+// RenderTemplate renders the page with a 200 OK
+func RenderTemplate(w http.ResponseWriter, r *http.Request, tmpl string, td *models.TemplateData){
+	RenderTemplateStatus(w, r, tmpl, td, http.StatusOK)
+}
+
+// RenderTemplateStatus renders the page with the given status, eg: an error page with a 500
+func RenderTemplateStatus(w http.ResponseWriter, r *http.Request, tmpl string, td *models.TemplateData, status int){
 	var tc map[string]*template.Template
 
-	if app.UseCache{
+	// in production the cache built at startup is always used, in development the templates are read again
+	// on every request so a changed template shows up on the next reload (unless the template watcher does that for us)
+	if app.InProduction{
 		// Get the template cache from the config.go
-		println("Using Saved Cache")
 		tc = app.TemplateCache
+	}else if watching.Load() {
+		tc = cachedTemplates()
 	}else {
-		println("Creating New Template everytime")
-		tc, _ = CreateTemplateCache()
+		if app.UseCache {
+			infoLog().Warn("UseCache is ignored in development, the templates are rebuilt on every request")
+		}
+		var err error
+		tc, err = CreateTemplateCache()
+		if err != nil {
+			errorLog().Error("could not build the template cache", "template", tmpl, "path", r.URL.Path, "error", err)
+			writeFallbackError(w, r)
+			return
+		}
 	}	
 
 	// get requested template from the cache
 	t, ok := tc[tmpl]
 	if !ok{
-		log.Fatal("Could not get template from template cache")
+		// a handler asking for a page that doesn't exist is a bug, but it's no reason to take the whole server down
+		errorLog().Error("could not get template from template cache", "template", tmpl, "path", r.URL.Path)
+		writeFallbackError(w, r)
+		return
 	}
 
-	// bytes.Buffer: A buffer to hold data temporarily in memory before writing it out.
-	buf := new(bytes.Buffer)
+	// the default data is added first: it pops the flash messages from the session, and a page showing one
+	// (or anything else that belongs to this request only) must not be answered with the copy the browser already has
+	td = AddDefaultData(td, r)
 
-	// t.Execute: Executes a template, injecting optional data.
-	err := t.Execute(buf, td)
+	// pages whose output only depends on their files can answer conditional GETs with a 304
+	if status == http.StatusOK && slices.Contains(app.ConditionalPages, tmpl) && !hasRequestData(tmpl, td) && notModified(w, r, tmpl) {
+		return
+	}
+
+	// safeExecute: Executes a template into a buffer (bytes.Buffer holds data temporarily in memory before writing it out).
+	// A panic inside the template is turned into an error.
+	buf, err := safeExecute(t, td, app.MaxResponseBytes)
 	if err != nil {
-		log.Println(err)
+		errorLog().Error("could not execute template", "template", tmpl, "path", r.URL.Path, "error", err)
+		// the half rendered buffer is thrown away, the visitor gets a complete (if plain) page instead
+		writeFallbackError(w, r)
+		return
+	}
+
+	// collapse the whitespace left by the templates to make the page smaller
+	if app.MinifyHTML {
+		buf, err = minifyHTML(buf)
+		if err != nil {
+			errorLog().Error("could not minify template", "template", tmpl, "path", r.URL.Path, "error", err)
+			writeFallbackError(w, r)
+			return
+		}
 	}
 
 	// render template
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
 	_, err = buf.WriteTo(w)
 	if err != nil {
-		log.Println(err)
+		errorLog().Error("could not write the page", "template", tmpl, "path", r.URL.Path, "error", err)
+	}
+}
+
+// fallbackErrorPage is sent when a page can't be rendered. It is written inline and doesn't use any template,
+// so it still works when the templates themselves are what is broken.
+const fallbackErrorPage = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Something went wrong</title>
+</head>
+<body>
+<h1>Something went wrong</h1>
+<p>Sorry, this page could not be shown. Please try again in a moment.</p>
+</body>
+</html>
+`
+
+// writeFallbackError answers with a 500 and the fallbackErrorPage, or the error fragment for htmx requests
+func writeFallbackError(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	// these described the page that failed, not this one
+	w.Header().Del("Last-Modified")
+	w.Header().Del("ETag")
+	if isHTMXRequest(r) {
+		writeHTMXError(w, http.StatusInternalServerError, "Sorry, this could not be shown. Please try again in a moment.")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(w, fallbackErrorPage)
+}
+
+// AddDefaultData adds the data every page gets (site defaults, the user, the referer, the year...) to the handler's TemplateData.
+// RenderTemplate calls it, so handlers only set what is special about their page.
+func AddDefaultData(td *models.TemplateData, r *http.Request) *models.TemplateData {
+	if td == nil {
+		td = &models.TemplateData{}
+	}
+
+	// fill in the app wide defaults (eg: site name) that the handler didn't set itself
+	td.Merge(app.DefaultTemplateData)
+
+	// every page gets link preview tags, the handler's own values win over the site defaults
+	og := models.OpenGraph{}
+	if td.OpenGraph != nil {
+		og = *td.OpenGraph
+	}
+	og = og.WithDefaults(app.DefaultOpenGraph)
+	if og.URL == "" && app.BaseURL != "" {
+		og.URL = strings.TrimSuffix(app.BaseURL, "/") + r.URL.Path
+	}
+	td.OpenGraph = &og
+
+	// the user the auth middleware found for this request, used by the hasRole template func
+	if td.User == nil {
+		td.User, _ = auth.UserFromContext(r.Context())
+	}
+
+	// the flag template func asks the evaluator the flags middleware put in the request context
+	td.FeatureFlag = func(name string) bool {
+		return flags.Enabled(r.Context(), name)
+	}
+
+	// the page the user came from, only when it is on this site so a "Back" link can't send them elsewhere
+	td.Referer = localReferer(r)
+
+	// for the copyright line in the footer
+	td.CurrentYear = time.Now().Year()
+
+	// the token the CSRF middleware expects back in the forms of the page
+	if token := csrf.Token(r.Context()); token != "" {
+		td.CSRFToken = token
+	}
+
+	// the messages the previous request left in the session (eg: "Reservation saved" before a redirect),
+	// popping them means they are only shown once
+	if msg := popSessionString(r, "flash"); msg != "" {
+		td.Flash = msg
+	}
+	if msg := popSessionString(r, "warning"); msg != "" {
+		td.Warning = msg
+	}
+	if msg := popSessionString(r, "error"); msg != "" {
+		td.Error = msg
+	}
+
+	return td
+}
+
+// popSessionString removes the key from the session and returns its value. A request the session middleware didn't
+// run for (eg: the error page of the recover middleware) has no session, scs panics on those so we return "".
+func popSessionString(r *http.Request, key string) (value string) {
+	if app.Session == nil {
+		return ""
+	}
+	defer func() {
+		if recover() != nil {
+			value = ""
+		}
+	}()
+	return app.Session.PopString(r.Context(), key)
+}
+
+// localReferer returns the path (and query) of the Referer header when it points to this site, otherwise ""
+func localReferer(r *http.Request) string {
+	ref := r.Referer()
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host != r.Host {
+		return ""
+	}
+	// "//evil.com" would be read by the browser as another site
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") {
+		return ""
+	}
+	return u.RequestURI()
+}
+
+// ErrResponseTooLarge is returned when a rendered page grows past AppConfig.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("render: response is larger than MaxResponseBytes")
+
+// safeExecute executes the template into a buffer and recovers from any panic raised while executing it (for example a
+// nil-map dereference inside a template func), so the handler gets back an error and can send a clean 500 instead of crashing.
+// When maxBytes is above 0 the execution is stopped as soon as the output grows past it, a template stuck in a big loop
+// can't eat all the memory that way.
+func safeExecute(t *template.Template, td *models.TemplateData, maxBytes int) (buf *bytes.Buffer, err error) {
+	buf = new(bytes.Buffer)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			buf = nil
+			err = fmt.Errorf("render: template %q panicked during execute: %v", t.Name(), rec)
+		}
+	}()
+
+	var w io.Writer = buf
+	if maxBytes > 0 {
+		w = &cappedWriter{buf: buf, max: maxBytes}
+	}
+
+	err = t.Execute(w, td)
+	if errors.Is(err, ErrResponseTooLarge) {
+		return nil, fmt.Errorf("%w: template %q produced more than %d bytes", ErrResponseTooLarge, t.Name(), maxBytes)
+	}
+	if err != nil {
+		// what was written before the error is half a page, nobody should send it
+		return nil, err
+	}
+	return buf, nil
+}
+
+// cappedWriter writes into buf until it holds max bytes, any write going past that fails with ErrResponseTooLarge
+type cappedWriter struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if c.buf.Len()+len(p) > c.max {
+		return 0, ErrResponseTooLarge
+	}
+	return c.buf.Write(p)
+}
+
+// hasRequestData reports if the rendered page would hold something that belongs to this request only:
+// a flash, warning or error message (the base layout shows them on every page), the logged in user, or the
+// CSRF token when the page renders it. Such a page gets no Last-Modified, so the browser never revalidates it into a 304.
+func hasRequestData(tmpl string, td *models.TemplateData) bool {
+	if td.Flash != "" || td.Warning != "" || td.Error != "" || td.User != nil {
+		return true
+	}
+	modTimesMu.RLock()
+	defer modTimesMu.RUnlock()
+	return td.CSRFToken != "" && templateUsesToken[tmpl]
+}
+
+// notModified sets the Last-Modified header of the page and reports true (after writing a 304) when the client's
+// If-Modified-Since shows it already has the latest version
+func notModified(w http.ResponseWriter, r *http.Request, tmpl string) bool {
+	modTimesMu.RLock()
+	modTime, ok := templateModTimes[tmpl]
+	modTimesMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	// http dates only have second precision
+	modTime = modTime.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modTime.After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// newestModTime returns the latest modification time of the given files
+func newestModTime(files ...string) (time.Time, error) {
+	var newest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return newest, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// defaultTemplateDir is where the templates are when no TemplateDirs are configured
+const defaultTemplateDir = "../../templates"
+
+// templateDirs returns the directories the templates are loaded from. Later directories win when two have a file with the same name.
+func templateDirs() []string {
+	if app == nil || len(app.TemplateDirs) == 0 {
+		return []string{defaultTemplateDir}
+	}
+	return app.TemplateDirs
+}
+
+// the default template file name patterns
+const (
+	defaultPageGlob    = "*.page.tmpl"
+	defaultLayoutGlob  = "*.layout.tmpl"
+	defaultPartialGlob = "partials/*.partial.tmpl"
+)
+
+// templateGlobs returns the patterns used to find the pages, layouts and partials, falling back to the defaults
+func templateGlobs() (pageGlob, layoutGlob, partialGlob string) {
+	pageGlob, layoutGlob, partialGlob = defaultPageGlob, defaultLayoutGlob, defaultPartialGlob
+	if app == nil {
+		return
+	}
+	if app.PageGlob != "" {
+		pageGlob = app.PageGlob
+	}
+	if app.LayoutGlob != "" {
+		layoutGlob = app.LayoutGlob
+	}
+	if app.PartialGlob != "" {
+		partialGlob = app.PartialGlob
+	}
+	return
+}
+
+// ValidateTemplateGlobs checks that the configured patterns are valid globs, call it at startup so a typo fails fast
+func ValidateTemplateGlobs() error {
+	pageGlob, layoutGlob, partialGlob := templateGlobs()
+	for _, pattern := range []string{pageGlob, layoutGlob, partialGlob} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("render: invalid template pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// globAll runs the glob pattern (eg: *.page.tmpl) in every directory, keeping the order of the directories
+func globAll(dirs []string, pattern string) ([]string, error) {
+	var all []string
+	for _, dir := range dirs {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, matches...)
+	}
+	return all, nil
+}
+
+// duplicateNames returns an error listing every file name that exists in more than one directory, or nil if there is none
+func duplicateNames(files []string) error {
+	byName := map[string][]string{}
+	var names []string
+	for _, file := range files {
+		name := filepath.Base(file)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], file)
 	}
+
+	var problems []string
+	for _, name := range names {
+		if len(byName[name]) > 1 {
+			problems = append(problems, fmt.Sprintf("%s (%s)", name, strings.Join(byName[name], ", ")))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("render: duplicate template names: %s", strings.Join(problems, "; "))
 }
 
 // This is a function to Create Template cache that returns a value that is map which has key : template_name and value : rendered template and a error
+// When app.TemplateFS is set (eg: the embedded templates) the templates are read from it instead of the TemplateDirs.
 func CreateTemplateCache() (map[string]*template.Template, error){
+	if app != nil && app.TemplateFS != nil {
+		return CreateTemplateCacheFS(app.TemplateFS)
+	}
 
 	// myCache := make(map[string]*template.Template) //creating map using make keyword
 	myCache := map[string]*template.Template{} //this is creating and empty map without make, keyword both are same
 
-	// get all of the files name *.page.tmpl from ./templates. 
+	dirs := templateDirs()
+	pageGlob, layoutGlob, partialGlob := templateGlobs()
+
+	// get all of the files name *.page.tmpl (or the configured PageGlob) from every template directory.
 	// filepath.Glob: Returns a list of files matching a glob pattern.
-	pages, err := filepath.Glob("../../templates/*.page.tmpl")
+	pages, err := globAll(dirs, pageGlob)
 	if err != nil{
 		return myCache, err
 	}
 
+	// the layouts and partials are shared by the pages, so we only need to look for them once
+	layouts, err := globAll(dirs, layoutGlob)
+	if err != nil{
+		return myCache, err
+	}
+	partials, err := globAll(dirs, partialGlob)
+	if err != nil{
+		return myCache, err
+	}
+
+	// in strict mode two files with the same name in different directories is an error instead of the last one silently winning
+	if app != nil && app.StrictTemplates {
+		err = duplicateNames(append(append(append([]string{}, pages...), layouts...), partials...))
+		if err != nil{
+			return myCache, err
+		}
+	}
+
 	// range through all files ending with *.page.tmpl
 	for _, page := range pages{
 
 		// filepath.Base: Extracts the base name of a file path.
 		name := filepath.Base(page)
 
-		// template.New: Creates a new template instance with a specific name.
-		// ParseFiles: Parses one or more template files into a template instance.
-		ts , err := template.New(name).ParseFiles(page)
+		// every page is parsed with its own layout (see LayoutFor) and all the partials
+		files, err := pageFiles(name, layouts, partials, filepath.Base)
 		if err != nil{
 			return myCache, err
 		}
 
-		matches, err := filepath.Glob("../../templates/*.layout.tmpl")
+		ts, err := parsePage(name, page, files)
 		if err != nil{
 			return myCache, err
 		}
 
-		if len(matches) > 0 {
-			//ParseGlob: Parses all template files matching a glob pattern into the template instance.
-			ts, err = ts.ParseGlob("../../templates/*.layout.tmpl")
-			if err != nil{
-				return myCache, err
+		// a page with the same name in a later directory replaces this one
+		myCache[name] = ts
+	}
+
+	return myCache, nil
+
+}
+
+// defaultLayout is the layout of the pages that have no entry in app.PageLayouts
+const defaultLayout = "base.layout.tmpl"
+
+// LayoutFor returns the file name of the layout the page extends: its entry in app.PageLayouts, else app.DefaultLayout,
+// else base.layout.tmpl. Every layout defines the same "base" template, so the page itself doesn't change
+// when it is moved to another layout, eg: PageLayouts: map[string]string{"dashboard.page.tmpl": "admin.layout.tmpl"}
+func LayoutFor(page string) string {
+	if app == nil {
+		return defaultLayout
+	}
+	if layout, ok := app.PageLayouts[page]; ok {
+		return layout
+	}
+	if app.DefaultLayout != "" {
+		return app.DefaultLayout
+	}
+	return defaultLayout
+}
+
+// pageFiles returns the files a page is parsed with: the layout LayoutFor picks (the one of the latest directory
+// when several have it) followed by all the partials. base turns a path into its file name, filepath.Base on the disk
+// and path.Base in an fs.FS.
+func pageFiles(page string, layouts, partials []string, base func(string) string) ([]string, error) {
+	// without any layout (eg: a folder of stand alone pages) there is nothing to pick
+	if len(layouts) == 0 {
+		return partials, nil
+	}
+
+	want := LayoutFor(page)
+	layout := ""
+	for _, l := range layouts {
+		if base(l) == want {
+			layout = l
+		}
+	}
+	if layout == "" {
+		return nil, fmt.Errorf("render: layout %q of %s not found", want, page)
+	}
+	return append([]string{layout}, partials...), nil
+}
+
+// parsePage parses one page file together with its layout and the partials and remembers when its files last changed
+func parsePage(name, page string, layouts []string) (*template.Template, error) {
+	// template.New: Creates a new template instance with a specific name.
+	// Funcs: Adds our helper functions, this has to happen before parsing so the templates can call them.
+	// ParseFiles: Parses one or more template files into a template instance.
+	ts := template.New(name).Funcs(functions)
+	// missingkey=error: a missing map key fails the render instead of quietly printing <no value>
+	if app != nil && app.StrictMissingKeys {
+		ts = ts.Option("missingkey=error")
+	}
+	ts, err := ts.ParseFiles(page)
+	if err != nil {
+		return nil, undefinedFuncError(name, err)
+	}
+
+	if len(layouts) > 0 {
+		//ParseFiles: Parses all the layout and partial files into the template instance.
+		ts, err = ts.ParseFiles(layouts...)
+		if err != nil {
+			return nil, undefinedFuncError(name, err)
+		}
+	}
+
+	// remember when the files of this page last changed, used for the Last-Modified header
+	modTime, err := newestModTime(append(append([]string{}, layouts...), page)...)
+	if err != nil {
+		return nil, err
+	}
+	modTimesMu.Lock()
+	templateModTimes[name] = modTime
+	templateUsesToken[name] = usesCSRFToken(ts)
+	modTimesMu.Unlock()
+
+	return ts, nil
+}
+
+// usesCSRFToken reports if any template of the set (the page, its layout or a partial) reads .CSRFToken or calls csrfField
+func usesCSRFToken(ts *template.Template) bool {
+	for _, t := range ts.Templates() {
+		if t.Tree != nil && nodeUsesCSRFToken(t.Tree.Root) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeUsesCSRFToken walks the parse tree of a template looking for the CSRF token
+func nodeUsesCSRFToken(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if nodeUsesCSRFToken(child) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesCSRFToken(n.Pipe)
+	case *parse.IfNode:
+		return nodeUsesCSRFToken(n.Pipe) || nodeUsesCSRFToken(n.List) || nodeUsesCSRFToken(n.ElseList)
+	case *parse.RangeNode:
+		return nodeUsesCSRFToken(n.Pipe) || nodeUsesCSRFToken(n.List) || nodeUsesCSRFToken(n.ElseList)
+	case *parse.WithNode:
+		return nodeUsesCSRFToken(n.Pipe) || nodeUsesCSRFToken(n.List) || nodeUsesCSRFToken(n.ElseList)
+	case *parse.TemplateNode:
+		return nodeUsesCSRFToken(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if nodeUsesCSRFToken(cmd) {
+				return true
 			}
 		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeUsesCSRFToken(arg) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return slices.Contains(n.Ident, "CSRFToken")
+	case *parse.ChainNode:
+		return slices.Contains(n.Field, "CSRFToken") || nodeUsesCSRFToken(n.Node)
+	case *parse.IdentifierNode:
+		return n.Ident == "csrfField"
+	}
+	return false
+}
+
+// CreateTemplateCacheFS builds the template cache from a file system instead of the disk, eg: an embed.FS so the
+// binary doesn't need the templates folder next to it. The PageGlob, LayoutGlob and PartialGlob are used inside fsys.
+func CreateTemplateCacheFS(fsys fs.FS) (map[string]*template.Template, error){
+	myCache := map[string]*template.Template{}
+
+	pageGlob, layoutGlob, partialGlob := templateGlobs()
+
+	// fs.Glob: like filepath.Glob, but inside fsys
+	pages, err := fs.Glob(fsys, pageGlob)
+	if err != nil{
+		return myCache, err
+	}
+	layouts, err := fs.Glob(fsys, layoutGlob)
+	if err != nil{
+		return myCache, err
+	}
+	partials, err := fs.Glob(fsys, partialGlob)
+	if err != nil{
+		return myCache, err
+	}
+
+	for _, page := range pages{
+		name := path.Base(page)
+
+		files, err := pageFiles(name, layouts, partials, path.Base)
+		if err != nil{
+			return myCache, err
+		}
+
+		ts := template.New(name).Funcs(functions)
+		if app != nil && app.StrictMissingKeys {
+			ts = ts.Option("missingkey=error")
+		}
+		// ParseFS: like ParseFiles, but the files are read from fsys
+		ts, err = ts.ParseFS(fsys, append([]string{page}, files...)...)
+		if err != nil{
+			return myCache, undefinedFuncError(name, err)
+		}
+
+		// embedded files have no modification time, those pages get the time the binary was built instead,
+		// so the conditional GET of ConditionalPages still works in the deployed binary
+		modTime, err := newestModTimeFS(fsys, append(append([]string{}, files...), page)...)
+		if err != nil{
+			return myCache, err
+		}
+		if modTime.IsZero() {
+			modTime = embedModTime
+		}
+		modTimesMu.Lock()
+		templateModTimes[name] = modTime
+		templateUsesToken[name] = usesCSRFToken(ts)
+		modTimesMu.Unlock()
 
 		myCache[name] = ts
 	}
 
 	return myCache, nil
+}
 
-}
\ No newline at end of file
+// embedModTime is the modification time of the embedded templates: when the binary holding them was built (its file's
+// modification time), or when the app started if that can't be found. A new deploy changes it, so browsers fetch the new pages.
+var embedModTime = buildTime()
+
+func buildTime() time.Time {
+	exe, err := os.Executable()
+	if err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Now()
+}
+
+// newestModTimeFS is newestModTime for files inside fsys
+func newestModTimeFS(fsys fs.FS, files ...string) (time.Time, error) {
+	var newest time.Time
+	for _, file := range files {
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			return newest, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// undefinedFuncPattern matches the parse error html/template gives for a func that was never registered, eg:
+// template: home.page.tmpl:3: function "myFunc" not defined
+var undefinedFuncPattern = regexp.MustCompile(`template: ([^:]+):\d+: function "([^"]+)" not defined`)
+
+// undefinedFuncError turns the terse "function not defined" parse error into one that names the file and the missing func.
+// Any other error is returned as it is.
+func undefinedFuncError(page string, err error) error {
+	m := undefinedFuncPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	return fmt.Errorf("render: building cache for %s: template %s calls function %q which is not registered: %w", page, m[1], m[2], err)
+}
@@ -0,0 +1,334 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/rahulrai17/porject/pkg/config"
+	"github.com/rahulrai17/porject/pkg/csrf"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// testLayout is a minimal base layout, it shows the flash like the real one does
+const testLayout = `{{define "base"}}<html><body>{{with .Flash}}<p class="flash">{{.}}</p>{{end}}{{block "content" .}}{{end}}</body></html>{{end}}`
+
+// writeTemplates writes the files (name => content) into a new temp dir and returns the dir
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// useTemplates points render at a fresh development config reading the templates from dir,
+// the previous config is put back when the test ends
+func useTemplates(t *testing.T, dir string) *config.AppConfig {
+	t.Helper()
+	old := app
+	a := &config.AppConfig{
+		TemplateDirs: []string{dir},
+		InfoLog:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ErrorLog:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	NewTemplates(a)
+	t.Cleanup(func() { app = old })
+	return a
+}
+
+// render runs RenderTemplate for the page and returns the recorded response
+func render(t *testing.T, r *http.Request, tmpl string, td *models.TemplateData) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	RenderTemplate(rr, r, tmpl, td)
+	return rr
+}
+
+func TestConditionalGet(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   `{{template "base" .}}{{define "content"}}<h1>Home</h1>{{end}}`,
+		"form.page.tmpl":   `{{template "base" .}}{{define "content"}}<form>{{csrfField .}}</form>{{end}}`,
+	})
+	a := useTemplates(t, dir)
+	a.ConditionalPages = []string{"home.page.tmpl", "form.page.tmpl"}
+
+	// a fixed modification time so the test doesn't depend on when the files were written
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, name := range []string{"base.layout.tmpl", "home.page.tmpl", "form.page.tmpl"} {
+		err := os.Chtimes(filepath.Join(dir, name), modTime, modTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lastModified := modTime.Format(http.TimeFormat)
+
+	t.Run("first request", func(t *testing.T) {
+		rr := render(t, httptest.NewRequest(http.MethodGet, "/home", nil), "home.page.tmpl", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rr.Code)
+		}
+		if got := rr.Header().Get("Last-Modified"); got != lastModified {
+			t.Errorf("Last-Modified = %q, want %q", got, lastModified)
+		}
+	})
+
+	t.Run("newer If-Modified-Since", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		rr := render(t, req, "home.page.tmpl", nil)
+		if rr.Code != http.StatusNotModified {
+			t.Fatalf("status = %d, want 304", rr.Code)
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("a 304 must not have a body, got %q", rr.Body)
+		}
+	})
+
+	t.Run("older If-Modified-Since", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req.Header.Set("If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat))
+		rr := render(t, req, "home.page.tmpl", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rr.Code)
+		}
+	})
+
+	t.Run("page with a flash", func(t *testing.T) {
+		a.Session = scs.New()
+		t.Cleanup(func() { a.Session = nil })
+		ctx, err := a.Session.Load(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Session.Put(ctx, "flash", "Saved!")
+
+		req := httptest.NewRequest(http.MethodGet, "/home", nil).WithContext(ctx)
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		rr := render(t, req, "home.page.tmpl", nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Saved!") {
+			t.Fatalf("got %d %q, want the full page with the flash", rr.Code, rr.Body)
+		}
+		if got := rr.Header().Get("Last-Modified"); got != "" {
+			t.Errorf("a page with a flash must not get a Last-Modified, got %q", got)
+		}
+		if a.Session.Exists(ctx, "flash") {
+			t.Error("the flash was not popped from the session")
+		}
+	})
+
+	t.Run("page rendering the CSRF token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/form", nil)
+		req = req.WithContext(csrf.NewContext(req.Context(), "token-of-this-browser"))
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		rr := render(t, req, "form.page.tmpl", nil)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "token-of-this-browser") {
+			t.Fatalf("got %d %q, want the full page with the current token", rr.Code, rr.Body)
+		}
+	})
+
+	t.Run("page not rendering the CSRF token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req = req.WithContext(csrf.NewContext(req.Context(), "token-of-this-browser"))
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		rr := render(t, req, "home.page.tmpl", nil)
+		if rr.Code != http.StatusNotModified {
+			t.Fatalf("status = %d, want 304", rr.Code)
+		}
+	})
+}
+
+func TestFallbackErrorPage(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		// the layout is written out before the missing field stops Execute halfway through the page
+		"missing.page.tmpl": `{{template "base" .}}{{define "content"}}<h1>Before</h1>{{.NotAField}}<p>After</p>{{end}}`,
+	}))
+
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{"execute fails halfway", "missing.page.tmpl"},
+		{"template not in the cache", "nothere.page.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := render(t, httptest.NewRequest(http.MethodGet, "/page", nil), tt.tmpl, nil)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", rr.Code)
+			}
+			if rr.Body.String() != fallbackErrorPage {
+				t.Errorf("want the complete fallback page, got %q", rr.Body)
+			}
+			if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+		})
+	}
+}
+
+func TestSafeExecutePanic(t *testing.T) {
+	funcs := template.FuncMap{
+		// a template func with a bug: it writes into a nil map
+		"remember": func(key string) string {
+			var seen map[string]bool
+			seen[key] = true
+			return key
+		},
+	}
+	tmpl := template.Must(template.New("panicky.page.tmpl").Funcs(funcs).Parse(`<p>{{remember "x"}}</p>`))
+
+	buf, err := safeExecute(tmpl, &models.TemplateData{}, 0)
+	if err == nil {
+		t.Fatal("want the panic as an error")
+	}
+	if buf != nil {
+		t.Errorf("the half rendered buffer was returned: %q", buf)
+	}
+	// html/template turns a panic inside a func into an error itself, anything else panicking is recovered by safeExecute
+	for _, want := range []string{"panicky.page.tmpl", "remember", "nil map"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestSafeExecute(t *testing.T) {
+	tmpl := template.Must(template.New("ok.page.tmpl").Parse(`<p>{{.Flash}}</p>`))
+
+	buf, err := safeExecute(tmpl, &models.TemplateData{Flash: "saved"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "<p>saved</p>" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRenderTemplatePanickingFunc(t *testing.T) {
+	useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		// index on a nil map is fine, but a nil *Form is dereferenced by its Get method
+		"nilform.page.tmpl": `{{template "base" .}}{{define "content"}}<p>{{.Form.Get "name"}}</p>{{end}}`,
+	}))
+
+	rr := render(t, httptest.NewRequest(http.MethodGet, "/nilform", nil), "nilform.page.tmpl", &models.TemplateData{})
+
+	if rr.Code != http.StatusInternalServerError || rr.Body.String() != fallbackErrorPage {
+		t.Errorf("got %d %q, want the fallback 500", rr.Code, rr.Body)
+	}
+}
+
+func TestUndefinedFuncError(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "in the page",
+			files: map[string]string{
+				"base.layout.tmpl": testLayout,
+				"home.page.tmpl":   `{{template "base" .}}{{define "content"}}{{myFunc .}}{{end}}`,
+			},
+			want: []string{"home.page.tmpl", `"myFunc"`, "not registered"},
+		},
+		{
+			name: "in the layout",
+			files: map[string]string{
+				"base.layout.tmpl": `{{define "base"}}{{siteName}}{{block "content" .}}{{end}}{{end}}`,
+				"about.page.tmpl":  `{{template "base" .}}{{define "content"}}<p>about</p>{{end}}`,
+			},
+			want: []string{"about.page.tmpl", "base.layout.tmpl", `"siteName"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTemplates(t, writeTemplates(t, tt.files))
+
+			_, err := CreateTemplateCache()
+			if err == nil {
+				t.Fatal("want an error for the unregistered func")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUndefinedFuncErrorOtherErrors(t *testing.T) {
+	// an error of another kind is passed on as it is
+	err := fmt.Errorf("template: home.page.tmpl:1: unexpected EOF")
+	if got := undefinedFuncError("home.page.tmpl", err); got != err {
+		t.Errorf("got %v, want the error unchanged", got)
+	}
+}
+
+func TestDefaultTemplateData(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   `{{template "base" .}}{{define "content"}}<h1>{{index .StringMap "site_name"}}</h1>{{end}}`,
+	}))
+	a.DefaultTemplateData = &models.TemplateData{StringMap: map[string]string{"site_name": "Bookings"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	if body := render(t, req, "home.page.tmpl", &models.TemplateData{}).Body.String(); !strings.Contains(body, "<h1>Bookings</h1>") {
+		t.Errorf("the default site name is missing: %s", body)
+	}
+
+	own := &models.TemplateData{StringMap: map[string]string{"site_name": "Special offer"}}
+	if body := render(t, req, "home.page.tmpl", own).Body.String(); !strings.Contains(body, "<h1>Special offer</h1>") {
+		t.Errorf("the handler's site name didn't win: %s", body)
+	}
+
+	// a nil td gets the defaults too
+	if body := render(t, req, "home.page.tmpl", nil).Body.String(); !strings.Contains(body, "<h1>Bookings</h1>") {
+		t.Errorf("nil data: %s", body)
+	}
+}
+
+func TestRenderLogsTemplateAndPath(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   `{{template "base" .}}{{define "content"}}<h1>Home</h1>{{end}}`,
+	}))
+	var logs strings.Builder
+	a.ErrorLog = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	render(t, httptest.NewRequest(http.MethodGet, "/rooms/nope", nil), "nope.page.tmpl", &models.TemplateData{})
+
+	// the error is one structured entry naming the template and the page that asked for it
+	line, _, _ := strings.Cut(logs.String(), "\n")
+	for _, want := range []string{`"level":"ERROR"`, `"template":"nope.page.tmpl"`, `"path":"/rooms/nope"`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("%q does not contain %s", line, want)
+		}
+	}
+}
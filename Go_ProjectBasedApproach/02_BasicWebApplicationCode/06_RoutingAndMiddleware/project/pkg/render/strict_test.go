@@ -0,0 +1,49 @@
+package render
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateTemplateNames(t *testing.T) {
+	first := writeTemplates(t, map[string]string{
+		"base.layout.tmpl": testLayout,
+		"home.page.tmpl":   `{{template "base" .}}{{define "content"}}first{{end}}`,
+		"about.page.tmpl":  `{{template "base" .}}{{define "content"}}about{{end}}`,
+	})
+	second := writeTemplates(t, map[string]string{
+		"home.page.tmpl": `{{template "base" .}}{{define "content"}}second{{end}}`,
+	})
+	a := useTemplates(t, first)
+	a.TemplateDirs = []string{first, second}
+
+	// by default the page of the later directory wins
+	cache, err := CreateTemplateCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := cache["home.page.tmpl"].Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "second") {
+		t.Errorf("home.page.tmpl = %q, want the one of the last dir", buf.String())
+	}
+
+	a.StrictTemplates = true
+	_, err = CreateTemplateCache()
+	if err == nil {
+		t.Fatal("the duplicate was accepted in strict mode")
+	}
+	msg := err.Error()
+	for _, want := range []string{"home.page.tmpl", filepath.Join(first, "home.page.tmpl"), filepath.Join(second, "home.page.tmpl")} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not name %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "about.page.tmpl") {
+		t.Errorf("error %q lists a file that is not duplicated", msg)
+	}
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"context"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watching is true while StartTemplateWatcher keeps watchedCache up to date, development then renders from it
+// instead of rebuilding every template on every request
+var watching atomic.Bool
+
+// watchedCache is the template cache kept fresh by the watcher
+var watchedCache = struct {
+	mu sync.RWMutex
+	tc map[string]*template.Template
+}{}
+
+// cachedTemplates returns the template cache the watcher keeps up to date
+func cachedTemplates() map[string]*template.Template {
+	watchedCache.mu.RLock()
+	defer watchedCache.mu.RUnlock()
+	return watchedCache.tc
+}
+
+// StartTemplateWatcher watches the template directories and rebuilds the cache when a template changes, so a
+// changed template shows up on the next reload without rebuilding every template on every request. A changed page
+// only rebuilds its own entry, a changed layout or partial rebuilds all of them since every page uses them.
+// It only runs in development (in production the templates can't change) and stops when ctx is done.
+func StartTemplateWatcher(ctx context.Context) error {
+	if app == nil || app.InProduction || app.TemplateFS != nil {
+		return nil
+	}
+
+	tc, err := CreateTemplateCache()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	_, _, partialGlob := templateGlobs()
+	for _, dir := range templateDirs() {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+		// the partials are in a sub directory (eg: partials/), fsnotify doesn't watch sub directories by itself
+		partialDir := filepath.Dir(filepath.Join(dir, partialGlob))
+		if _, err := os.Stat(partialDir); err == nil && partialDir != dir {
+			if err := watcher.Add(partialDir); err != nil {
+				watcher.Close()
+				return err
+			}
+		}
+	}
+
+	watchedCache.mu.Lock()
+	watchedCache.tc = tc
+	watchedCache.mu.Unlock()
+	watching.Store(true)
+
+	go func() {
+		defer watcher.Close()
+		defer watching.Store(false)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Chmod) || filepath.Ext(event.Name) != ".tmpl" {
+					continue
+				}
+				if err := rebuildTemplate(event.Name); err != nil {
+					// the old entry is kept, fixing the template triggers the next rebuild
+					errorLog().Error("could not rebuild the template", "file", event.Name, "error", err)
+					continue
+				}
+				infoLog().Info("template rebuilt", "file", event.Name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				errorLog().Error("template watcher", "error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// rebuildTemplate updates the watched cache after file changed
+func rebuildTemplate(file string) error {
+	pageGlob, _, _ := templateGlobs()
+	name := filepath.Base(file)
+
+	// a layout or partial (or a page that was removed or renamed) changes the whole cache
+	isPage, _ := filepath.Match(pageGlob, name)
+	if _, err := os.Stat(file); !isPage || err != nil {
+		tc, err := CreateTemplateCache()
+		if err != nil {
+			return err
+		}
+		watchedCache.mu.Lock()
+		watchedCache.tc = tc
+		watchedCache.mu.Unlock()
+		return nil
+	}
+
+	_, layoutGlob, partialGlob := templateGlobs()
+	dirs := templateDirs()
+	layouts, err := globAll(dirs, layoutGlob)
+	if err != nil {
+		return err
+	}
+	partials, err := globAll(dirs, partialGlob)
+	if err != nil {
+		return err
+	}
+
+	files, err := pageFiles(name, layouts, partials, filepath.Base)
+	if err != nil {
+		return err
+	}
+
+	ts, err := parsePage(name, file, files)
+	if err != nil {
+		return err
+	}
+
+	// the map is copied so a request reading the old one is never raced
+	watchedCache.mu.Lock()
+	tc := make(map[string]*template.Template, len(watchedCache.tc)+1)
+	for k, v := range watchedCache.tc {
+		tc[k] = v
+	}
+	tc[name] = ts
+	watchedCache.tc = tc
+	watchedCache.mu.Unlock()
+	return nil
+}
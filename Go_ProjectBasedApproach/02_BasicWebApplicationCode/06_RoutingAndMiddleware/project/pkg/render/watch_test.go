@@ -0,0 +1,90 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// startWatcher runs StartTemplateWatcher until the test ends, the cleanup waits for it to stop so
+// the next test doesn't render from this test's cache
+func startWatcher(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := StartTemplateWatcher(ctx); err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		for watching.Load() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+// eventually renders the page until the body contains want, the watcher rebuilds a moment after the write
+func eventually(t *testing.T, page, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rr := render(t, httptest.NewRequest(http.MethodGet, "/", nil), page, &models.TemplateData{})
+		if strings.Contains(rr.Body.String(), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s still renders %q, want %q", page, rr.Body, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTemplateWatcher(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.layout.tmpl":          `{{define "base"}}<html><body>{{template "nav" .}}{{block "content" .}}{{end}}</body></html>{{end}}`,
+		"home.page.tmpl":            `{{template "base" .}}{{define "content"}}<h1>Before</h1>{{end}}`,
+		"about.page.tmpl":           `{{template "base" .}}{{define "content"}}<h1>About</h1>{{end}}`,
+		"partials/nav.partial.tmpl": `{{define "nav"}}<nav>old menu</nav>{{end}}`,
+	})
+	useTemplates(t, dir)
+	startWatcher(t)
+	if !watching.Load() {
+		t.Fatal("the watcher is not running in development")
+	}
+	eventually(t, "home.page.tmpl", "<h1>Before</h1>")
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a changed page shows up without a restart
+	write("home.page.tmpl", `{{template "base" .}}{{define "content"}}<h1>After</h1>{{end}}`)
+	eventually(t, "home.page.tmpl", "<h1>After</h1>")
+
+	// a changed partial (in its sub directory) rebuilds every page
+	write("partials/nav.partial.tmpl", `{{define "nav"}}<nav>new menu</nav>{{end}}`)
+	eventually(t, "about.page.tmpl", "<nav>new menu</nav>")
+	eventually(t, "home.page.tmpl", "<nav>new menu</nav>")
+}
+
+func TestTemplateWatcherProduction(t *testing.T) {
+	a := useTemplates(t, writeTemplates(t, map[string]string{"home.page.tmpl": `home`}))
+	a.InProduction = true
+
+	if err := StartTemplateWatcher(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if watching.Load() {
+		t.Error("the watcher runs in production")
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// MemoryStore is a DataStore that keeps everything in memory, the data is lost when the app stops
+type MemoryStore struct {
+	mu           sync.RWMutex
+	reservations []models.Reservation
+	nextID       int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// AllReservations returns a copy of every reservation, oldest first
+func (s *MemoryStore) AllReservations(ctx context.Context) ([]models.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// returning a copy so the caller can't change our slice
+	out := make([]models.Reservation, len(s.reservations))
+	copy(out, s.reservations)
+	return out, nil
+}
+
+// CreateReservation stores the reservation and returns it with its new id
+func (s *MemoryStore) CreateReservation(ctx context.Context, res models.Reservation) (models.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Reservation{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res.ID = s.nextID
+	s.nextID++
+	if res.CreatedAt.IsZero() {
+		res.CreatedAt = time.Now()
+	}
+	s.reservations = append(s.reservations, res)
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+func TestMemoryStoreCreateReservation(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	first, err := store.CreateReservation(ctx, models.Reservation{ID: 42, FirstName: "Rahul"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the store picks the id, not the caller
+	if first.ID != 1 || first.FirstName != "Rahul" || first.CreatedAt.IsZero() {
+		t.Errorf("first = %+v", first)
+	}
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	second, err := store.CreateReservation(ctx, models.Reservation{FirstName: "Rai", CreatedAt: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != 2 || !second.CreatedAt.Equal(created) {
+		t.Errorf("second = %+v", second)
+	}
+
+	all, err := store.AllReservations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0] != first || all[1] != second {
+		t.Errorf("all = %+v", all)
+	}
+
+	// the list is a copy, changing it doesn't change the store
+	all[0].FirstName = "changed"
+	again, _ := store.AllReservations(ctx)
+	if again[0].FirstName != "Rahul" {
+		t.Error("AllReservations returned the store's own slice")
+	}
+}
+
+func TestMemoryStoreCanceledContext(t *testing.T) {
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := store.CreateReservation(ctx, models.Reservation{FirstName: "Rahul"})
+	if err == nil {
+		t.Error("want the context's error")
+	}
+	_, err = store.AllReservations(ctx)
+	if err == nil {
+		t.Error("want the context's error")
+	}
+}
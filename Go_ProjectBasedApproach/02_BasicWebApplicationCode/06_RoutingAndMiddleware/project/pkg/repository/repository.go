@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// DataStore is everything the handlers need from the storage layer. Handlers only talk to this interface,
+// so the in-memory store used today can be swapped for a real database without touching them.
+type DataStore interface {
+	AllReservations(ctx context.Context) ([]models.Reservation, error)
+	// CreateReservation stores res and returns the stored record, with the id (and created_at) the store gave it
+	CreateReservation(ctx context.Context, res models.Reservation) (models.Reservation, error)
+}
@@ -0,0 +1,87 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+// Store is a repository.DataStore backed by a SQL database (written for postgres placeholders)
+type Store struct {
+	db                *sql.DB
+	allReservations   *sql.Stmt
+	createReservation *sql.Stmt
+}
+
+// NewStore prepares all the statements the store uses on db, so a broken query fails at startup instead of on the first request
+func NewStore(db *sql.DB) (*Store, error) {
+	s := &Store{db: db}
+
+	var err error
+	s.allReservations, err = db.Prepare(`
+		SELECT id, first_name, last_name, email, phone, created_at
+		FROM reservations
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: preparing all reservations: %w", err)
+	}
+
+	s.createReservation, err = db.Prepare(`
+		INSERT INTO reservations (first_name, last_name, email, phone, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`)
+	if err != nil {
+		s.allReservations.Close()
+		return nil, fmt.Errorf("sqlstore: preparing create reservation: %w", err)
+	}
+
+	return s, nil
+}
+
+// AllReservations returns every reservation, oldest first. The query is cancelled if ctx is (eg: the client went away).
+func (s *Store) AllReservations(ctx context.Context) ([]models.Reservation, error) {
+	rows, err := s.allReservations.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: all reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []models.Reservation
+	for rows.Next() {
+		var res models.Reservation
+		err := rows.Scan(&res.ID, &res.FirstName, &res.LastName, &res.Email, &res.Phone, &res.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlstore: scanning reservation: %w", err)
+		}
+		reservations = append(reservations, res)
+	}
+
+	// rows.Err reports any error that stopped the loop early
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlstore: all reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// CreateReservation inserts the reservation and returns it as the database stored it, with its id
+// (and created_at, which comes back in the precision of the column)
+func (s *Store) CreateReservation(ctx context.Context, res models.Reservation) (models.Reservation, error) {
+	if res.CreatedAt.IsZero() {
+		res.CreatedAt = time.Now()
+	}
+
+	err := s.createReservation.QueryRowContext(ctx, res.FirstName, res.LastName, res.Email, res.Phone, res.CreatedAt).Scan(&res.ID, &res.CreatedAt)
+	if err != nil {
+		return models.Reservation{}, fmt.Errorf("sqlstore: create reservation: %w", err)
+	}
+	return res, nil
+}
+
+// Close releases the prepared statements, the *sql.DB itself is closed by whoever opened it
+func (s *Store) Close() error {
+	s.allReservations.Close()
+	return s.createReservation.Close()
+}
@@ -0,0 +1,169 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rahulrai17/porject/pkg/models"
+)
+
+var (
+	allQuery    = regexp.QuoteMeta(`SELECT id, first_name, last_name, email, phone, created_at FROM reservations ORDER BY id`)
+	createQuery = regexp.QuoteMeta(`INSERT INTO reservations (first_name, last_name, email, phone, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`)
+)
+
+// newMockStore returns a Store on a sqlmock database with both statements prepared
+func newMockStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+	// the queries are compared without the line breaks and indentation of the source
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+		actual = regexp.MustCompile(`\s+`).ReplaceAllString(actual, " ")
+		return sqlmock.QueryMatcherRegexp.Match(expected, actual)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	all := mock.ExpectPrepare(allQuery)
+	create := mock.ExpectPrepare(createQuery)
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the statements are reused, every query below runs on them
+	all.WillBeClosed()
+	create.WillBeClosed()
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return store, mock
+}
+
+func TestAllReservations(t *testing.T) {
+	store, mock := newMockStore(t)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(allQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "phone", "created_at"}).
+		AddRow(1, "Rahul", "Rai", "rahul@example.com", "555", created).
+		AddRow(2, "Sam", "Lee", "sam@example.com", "", created.Add(time.Hour)))
+
+	got, err := store.AllReservations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.Reservation{
+		{ID: 1, FirstName: "Rahul", LastName: "Rai", Email: "rahul@example.com", Phone: "555", CreatedAt: created},
+		{ID: 2, FirstName: "Sam", LastName: "Lee", Email: "sam@example.com", CreatedAt: created.Add(time.Hour)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d reservations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("reservation %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	store.Close()
+}
+
+func TestAllReservationsErrors(t *testing.T) {
+	columns := []string{"id", "first_name", "last_name", "email", "phone", "created_at"}
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{"query fails", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(allQuery).WillReturnError(errors.New("connection reset"))
+		}},
+		{"bad row", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(allQuery).WillReturnRows(sqlmock.NewRows(columns).AddRow("not a number", "", "", "", "", time.Now()))
+		}},
+		{"rows stop early", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(allQuery).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "Rahul", "", "", "", time.Now()).
+				AddRow(2, "Sam", "", "", "", time.Now()).
+				RowError(1, errors.New("connection reset")))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, mock := newMockStore(t)
+			tt.expect(mock)
+
+			got, err := store.AllReservations(context.Background())
+			if err == nil {
+				t.Fatalf("want an error, got %+v", got)
+			}
+			if got != nil {
+				t.Errorf("got reservations with the error: %+v", got)
+			}
+			store.Close()
+		})
+	}
+}
+
+func TestAllReservationsCanceled(t *testing.T) {
+	store, mock := newMockStore(t)
+	mock.ExpectQuery(allQuery).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := store.AllReservations(ctx)
+	// the query stops with the request instead of running to the end
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("err = %v after %v, want the query cancelled right away", err, time.Since(start))
+	}
+	store.Close()
+}
+
+func TestCreateReservation(t *testing.T) {
+	store, mock := newMockStore(t)
+	stored := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(createQuery).
+		WithArgs("Rahul", "Rai", "rahul@example.com", "555", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, stored))
+
+	got, err := store.CreateReservation(context.Background(), models.Reservation{FirstName: "Rahul", LastName: "Rai", Email: "rahul@example.com", Phone: "555"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Reservation{ID: 7, FirstName: "Rahul", LastName: "Rai", Email: "rahul@example.com", Phone: "555", CreatedAt: stored}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	store.Close()
+}
+
+func TestCreateReservationError(t *testing.T) {
+	store, mock := newMockStore(t)
+	mock.ExpectQuery(createQuery).WillReturnError(errors.New("duplicate key"))
+
+	_, err := store.CreateReservation(context.Background(), models.Reservation{FirstName: "Rahul"})
+	if err == nil || !regexp.MustCompile(`^sqlstore: create reservation: duplicate key$`).MatchString(err.Error()) {
+		t.Errorf("err = %v", err)
+	}
+	store.Close()
+}
+
+func TestNewStorePrepareError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectPrepare("SELECT").WillReturnError(errors.New("syntax error"))
+
+	_, err = NewStore(db)
+	if err == nil {
+		t.Error("a broken query must fail at startup")
+	}
+}
@@ -0,0 +1,20 @@
+package requestid
+
+import "context"
+
+// Header is the HTTP header the request id travels in, both on our responses and on outbound calls
+const Header = "X-Request-ID"
+
+// contextKey is unexported so no other package can overwrite our value in the context
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the request id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id stored in ctx, if there is one
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
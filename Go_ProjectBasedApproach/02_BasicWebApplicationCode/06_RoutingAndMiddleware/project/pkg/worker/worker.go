@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// Job is a piece of work that runs in the background after the request returned (eg: sending an email)
+type Job func(ctx context.Context) error
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the queue has no room left
+	ErrQueueFull = errors.New("worker: queue is full")
+	// ErrClosed is returned by Submit once the pool is shutting down
+	ErrClosed = errors.New("worker: pool is closed")
+)
+
+// Pool runs jobs on a fixed number of goroutines, so a burst of requests can't start an unbounded number of them
+type Pool struct {
+	jobs   chan Job
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+
+	// ErrorLog is where failed and panicking jobs are logged, nil means the standard logger. Set it before the first Submit.
+	ErrorLog *log.Logger
+	// OnPanic, when set, is called after a panicking job was recovered (eg: to count it). Set it before the first Submit.
+	OnPanic func()
+}
+
+// New starts a pool with the given number of workers and room for queueSize waiting jobs
+func New(workers, queueSize int) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		jobs:   make(chan Job, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues the job without blocking the caller
+func (p *Pool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// work takes jobs from the queue until it is closed and drained
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		p.run(job)
+	}
+}
+
+// run executes one job, a panicking job is recovered so it can't take the worker (or the whole app) down with it.
+// The panic is logged with its stack so it isn't lost, and the worker carries on with the next job.
+func (p *Pool) run(job Job) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			p.logf("worker: job panicked: %v\n%s", rec, debug.Stack())
+			if p.OnPanic != nil {
+				p.OnPanic()
+			}
+		}
+	}()
+
+	if err := job(p.ctx); err != nil {
+		p.logf("worker: job failed: %v", err)
+	}
+}
+
+// logf writes to ErrorLog, or to the standard logger when it isn't set
+func (p *Pool) logf(format string, args ...interface{}) {
+	if p.ErrorLog != nil {
+		p.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Shutdown stops accepting jobs and waits for the queued ones to finish. If ctx ends first,
+// the context given to the running jobs is cancelled and ctx's error is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// quietPool is a pool whose error log goes into the returned buffer
+func quietPool(t *testing.T, workers, queueSize int) (*Pool, *syncBuffer) {
+	t.Helper()
+	var buf syncBuffer
+	p := New(workers, queueSize)
+	p.ErrorLog = log.New(&buf, "", 0)
+	t.Cleanup(func() { p.Shutdown(context.Background()) })
+	return p, &buf
+}
+
+// syncBuffer is a bytes.Buffer the workers can write to while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSubmitRunsJob(t *testing.T) {
+	p, _ := quietPool(t, 2, 10)
+
+	done := make(chan struct{})
+	err := p.Submit(func(ctx context.Context) error {
+		close(done)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the job never ran")
+	}
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	p, logs := quietPool(t, 1, 10)
+	var panics atomic.Int32
+	p.OnPanic = func() { panics.Add(1) }
+
+	p.Submit(func(ctx context.Context) error { panic("boom") })
+
+	// the single worker survived the panic and runs the next job
+	done := make(chan struct{})
+	p.Submit(func(ctx context.Context) error {
+		close(done)
+		return nil
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the worker died with the panicking job")
+	}
+
+	if panics.Load() != 1 {
+		t.Errorf("OnPanic was called %d times, want 1", panics.Load())
+	}
+	if !strings.Contains(logs.String(), "job panicked: boom") {
+		t.Errorf("the panic was not logged: %q", logs)
+	}
+}
+
+func TestFailedJobIsLogged(t *testing.T) {
+	p, logs := quietPool(t, 1, 10)
+	p.Submit(func(ctx context.Context) error { return errors.New("smtp is down") })
+	p.Shutdown(context.Background())
+
+	if !strings.Contains(logs.String(), "job failed: smtp is down") {
+		t.Errorf("the error was not logged: %q", logs)
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	p, _ := quietPool(t, 1, 10)
+
+	// hold the only worker so the rest of the jobs wait in the queue
+	release := make(chan struct{})
+	var ran atomic.Int32
+	p.Submit(func(ctx context.Context) error {
+		<-release
+		ran.Add(1)
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		p.Submit(func(ctx context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	close(release)
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ran.Load() != 6 {
+		t.Errorf("%d jobs ran before Shutdown returned, want 6", ran.Load())
+	}
+	if err := p.Submit(func(ctx context.Context) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdownTimeoutCancelsJobs(t *testing.T) {
+	p, _ := quietPool(t, 1, 10)
+
+	cancelled := make(chan struct{})
+	p.Submit(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the running job's context was not cancelled")
+	}
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	p, _ := quietPool(t, 1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	// the worker is busy, one job fits in the queue and the next one doesn't
+	if err := p.Submit(func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Submit(func(ctx context.Context) error { return nil }); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit = %v, want ErrQueueFull", err)
+	}
+}
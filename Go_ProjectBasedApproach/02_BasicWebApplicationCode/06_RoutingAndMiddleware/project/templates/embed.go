@@ -0,0 +1,10 @@
+// Package templates embeds the page, layout and partial templates into the binary,
+// so a deployed binary doesn't need the templates folder next to it.
+package templates
+
+import "embed"
+
+// FS holds every template of this folder
+//
+//go:embed *.tmpl partials/*.tmpl
+var FS embed.FS